@@ -0,0 +1,196 @@
+package db_installer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AccountOptions are the resource limits, password policy and lock state install sets on the accounts of a role,
+// options left at ACCOUNT_OPTION_UNSET are not touched so limits set by hand on the server survive an install
+type AccountOptions struct {
+	GetMaxQueriesPerHour          func() int
+	SetMaxQueriesPerHour          func(max_queries_per_hour int)
+	GetMaxUpdatesPerHour          func() int
+	SetMaxUpdatesPerHour          func(max_updates_per_hour int)
+	GetMaxConnectionsPerHour      func() int
+	SetMaxConnectionsPerHour      func(max_connections_per_hour int)
+	GetMaxUserConnections         func() int
+	SetMaxUserConnections         func(max_user_connections int)
+	GetPasswordExpireIntervalDays func() int
+	SetPasswordExpireIntervalDays func(password_expire_interval_days int)
+	GetFailedLoginAttempts        func() int
+	SetFailedLoginAttempts        func(failed_login_attempts int)
+	GetPasswordLockTimeDays       func() int
+	SetPasswordLockTimeDays       func(password_lock_time_days int)
+	GetAccountLocked              func() *bool
+	SetAccountLocked              func(account_locked *bool)
+	IsEmpty                       func() bool
+	GetSQL                        func() string
+	Validate                      func() []error
+}
+
+func NewAccountOptions() *AccountOptions {
+	max_queries_per_hour := ACCOUNT_OPTION_UNSET()
+	max_updates_per_hour := ACCOUNT_OPTION_UNSET()
+	max_connections_per_hour := ACCOUNT_OPTION_UNSET()
+	max_user_connections := ACCOUNT_OPTION_UNSET()
+	password_expire_interval_days := ACCOUNT_OPTION_UNSET()
+	failed_login_attempts := ACCOUNT_OPTION_UNSET()
+	password_lock_time_days := ACCOUNT_OPTION_UNSET()
+	var account_locked *bool
+
+	// getResourceOptionsSQL is the WITH part of ALTER USER, 0 removes a limit
+	getResourceOptionsSQL := func() string {
+		var resource_options []string
+		for _, resource_option := range []struct {
+			name  string
+			value int
+		}{
+			{"MAX_QUERIES_PER_HOUR", max_queries_per_hour},
+			{"MAX_UPDATES_PER_HOUR", max_updates_per_hour},
+			{"MAX_CONNECTIONS_PER_HOUR", max_connections_per_hour},
+			{"MAX_USER_CONNECTIONS", max_user_connections},
+		} {
+			if resource_option.value != ACCOUNT_OPTION_UNSET() {
+				resource_options = append(resource_options, resource_option.name+" "+strconv.Itoa(resource_option.value))
+			}
+		}
+
+		if len(resource_options) == 0 {
+			return ""
+		}
+		return "WITH " + strings.Join(resource_options, " ")
+	}
+
+	getPasswordOptionsSQL := func() string {
+		var password_options []string
+		if password_expire_interval_days == 0 {
+			password_options = append(password_options, "PASSWORD EXPIRE NEVER")
+		} else if password_expire_interval_days != ACCOUNT_OPTION_UNSET() {
+			password_options = append(password_options, "PASSWORD EXPIRE INTERVAL "+strconv.Itoa(password_expire_interval_days)+" DAY")
+		}
+
+		if failed_login_attempts != ACCOUNT_OPTION_UNSET() {
+			password_options = append(password_options, "FAILED_LOGIN_ATTEMPTS "+strconv.Itoa(failed_login_attempts))
+		}
+
+		if password_lock_time_days == PASSWORD_LOCK_TIME_UNBOUNDED() {
+			password_options = append(password_options, "PASSWORD_LOCK_TIME UNBOUNDED")
+		} else if password_lock_time_days != ACCOUNT_OPTION_UNSET() {
+			password_options = append(password_options, "PASSWORD_LOCK_TIME "+strconv.Itoa(password_lock_time_days))
+		}
+
+		if account_locked != nil && *account_locked {
+			password_options = append(password_options, "ACCOUNT LOCK")
+		} else if account_locked != nil {
+			password_options = append(password_options, "ACCOUNT UNLOCK")
+		}
+		return strings.Join(password_options, " ")
+	}
+
+	getSQL := func() string {
+		var options []string
+		for _, option := range [...]string{getResourceOptionsSQL(), getPasswordOptionsSQL()} {
+			if option != "" {
+				options = append(options, option)
+			}
+		}
+		return strings.Join(options, " ")
+	}
+
+	validate := func() []error {
+		var errors []error
+		for _, resource_option := range []struct {
+			name  string
+			value int
+		}{
+			{"max_queries_per_hour", max_queries_per_hour},
+			{"max_updates_per_hour", max_updates_per_hour},
+			{"max_connections_per_hour", max_connections_per_hour},
+			{"max_user_connections", max_user_connections},
+		} {
+			if resource_option.value != ACCOUNT_OPTION_UNSET() && resource_option.value < 0 {
+				errors = append(errors, fmt.Errorf("account option: %s: %d cannot be negative, 0 removes the limit", resource_option.name, resource_option.value))
+			}
+		}
+
+		if password_expire_interval_days != ACCOUNT_OPTION_UNSET() && (password_expire_interval_days < 0 || password_expire_interval_days > 65535) {
+			errors = append(errors, fmt.Errorf("account option: password_expire_interval_days: %d must be between 0 (never) and 65535", password_expire_interval_days))
+		}
+
+		if failed_login_attempts != ACCOUNT_OPTION_UNSET() && (failed_login_attempts < 0 || failed_login_attempts > 32767) {
+			errors = append(errors, fmt.Errorf("account option: failed_login_attempts: %d must be between 0 and 32767", failed_login_attempts))
+		}
+
+		if password_lock_time_days != ACCOUNT_OPTION_UNSET() && password_lock_time_days != PASSWORD_LOCK_TIME_UNBOUNDED() && (password_lock_time_days < 0 || password_lock_time_days > 32767) {
+			errors = append(errors, fmt.Errorf("account option: password_lock_time_days: %d must be between 0 and 32767 or unbounded", password_lock_time_days))
+		}
+
+		if len(errors) > 0 {
+			return errors
+		}
+
+		return nil
+	}
+
+	return &AccountOptions{
+		GetMaxQueriesPerHour: func() int {
+			return max_queries_per_hour
+		},
+		SetMaxQueriesPerHour: func(value int) {
+			max_queries_per_hour = value
+		},
+		GetMaxUpdatesPerHour: func() int {
+			return max_updates_per_hour
+		},
+		SetMaxUpdatesPerHour: func(value int) {
+			max_updates_per_hour = value
+		},
+		GetMaxConnectionsPerHour: func() int {
+			return max_connections_per_hour
+		},
+		SetMaxConnectionsPerHour: func(value int) {
+			max_connections_per_hour = value
+		},
+		GetMaxUserConnections: func() int {
+			return max_user_connections
+		},
+		SetMaxUserConnections: func(value int) {
+			max_user_connections = value
+		},
+		GetPasswordExpireIntervalDays: func() int {
+			return password_expire_interval_days
+		},
+		SetPasswordExpireIntervalDays: func(value int) {
+			password_expire_interval_days = value
+		},
+		GetFailedLoginAttempts: func() int {
+			return failed_login_attempts
+		},
+		SetFailedLoginAttempts: func(value int) {
+			failed_login_attempts = value
+		},
+		GetPasswordLockTimeDays: func() int {
+			return password_lock_time_days
+		},
+		SetPasswordLockTimeDays: func(value int) {
+			password_lock_time_days = value
+		},
+		GetAccountLocked: func() *bool {
+			return account_locked
+		},
+		SetAccountLocked: func(value *bool) {
+			account_locked = value
+		},
+		IsEmpty: func() bool {
+			return getSQL() == ""
+		},
+		GetSQL: func() string {
+			return getSQL()
+		},
+		Validate: func() []error {
+			return validate()
+		},
+	}
+}
@@ -0,0 +1,111 @@
+package db_installer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ClientTLS is the ca, certificate and key a mysql client connects with, it is written into option files
+// as ssl-* lines or passed to the mysql client as --ssl-* options. mode is one of the --ssl-mode values that
+// never fall back to a plaintext connection
+type ClientTLS struct {
+	GetCA              func() string
+	GetCert            func() string
+	GetKey             func() string
+	GetMode            func() string
+	GetOptionFileLines func() string
+	GetCommandOptions  func() string
+	Validate           func() []error
+}
+
+func getClientTLSModes() []string {
+	return []string{"REQUIRED", "VERIFY_CA", "VERIFY_IDENTITY"}
+}
+
+func NewClientTLS(ca string, cert string, key string, mode string) *ClientTLS {
+	tls_mode := strings.ToUpper(strings.TrimSpace(mode))
+	if tls_mode == "" {
+		tls_mode = TLS_MODE_DEFAULT()
+	}
+
+	getOptionFileLines := func() string {
+		var lines strings.Builder
+		if ca != "" {
+			lines.WriteString("ssl-ca=" + ca + "\n")
+		}
+
+		if cert != "" {
+			lines.WriteString("ssl-cert=" + cert + "\n")
+		}
+
+		if key != "" {
+			lines.WriteString("ssl-key=" + key + "\n")
+		}
+		lines.WriteString("ssl-mode=" + tls_mode + "\n")
+		return lines.String()
+	}
+
+	// getCommandOptions are single quoted for the shell, they override the ssl-* lines of an option file
+	getCommandOptions := func() string {
+		var options strings.Builder
+		options.WriteString("--ssl-mode=" + tls_mode)
+		for _, option := range [][2]string{{"ssl-ca", ca}, {"ssl-cert", cert}, {"ssl-key", key}} {
+			if option[1] != "" {
+				options.WriteString(" --" + option[0] + "='" + strings.ReplaceAll(option[1], "'", `'\''`) + "'")
+			}
+		}
+		return options.String()
+	}
+
+	validate := func() []error {
+		var errors []error
+		if !containsString(getClientTLSModes(), tls_mode) {
+			errors = append(errors, fmt.Errorf("tls mode: %s is not supported, use one of %s", tls_mode, strings.Join(getClientTLSModes(), ", ")))
+		}
+
+		if ca == "" && tls_mode != "REQUIRED" {
+			errors = append(errors, fmt.Errorf("tls mode: %s needs a ca to verify the server certificate", tls_mode))
+		}
+
+		if (cert == "") != (key == "") {
+			errors = append(errors, fmt.Errorf("tls cert and key must be set together"))
+		}
+
+		for _, path := range [...]string{ca, cert, key} {
+			if path != "" && (!filepath.IsAbs(path) || strings.ContainsAny(path, "\n\r")) {
+				errors = append(errors, fmt.Errorf("tls file: %s must be an absolute path", path))
+			}
+		}
+
+		if len(errors) > 0 {
+			return errors
+		}
+
+		return nil
+	}
+
+	return &ClientTLS{
+		GetCA: func() string {
+			return ca
+		},
+		GetCert: func() string {
+			return cert
+		},
+		GetKey: func() string {
+			return key
+		},
+		GetMode: func() string {
+			return tls_mode
+		},
+		GetOptionFileLines: func() string {
+			return getOptionFileLines()
+		},
+		GetCommandOptions: func() string {
+			return getCommandOptions()
+		},
+		Validate: func() []error {
+			return validate()
+		},
+	}
+}
@@ -0,0 +1,30 @@
+package db_installer
+
+import (
+	"testing"
+)
+
+func TestClientTLSCommandOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		client_tls *ClientTLS
+		want       string
+	}{
+		{"default mode", NewClientTLS("/etc/mysql/ca.pem", "", "", ""), "--ssl-mode=VERIFY_IDENTITY --ssl-ca='/etc/mysql/ca.pem'"},
+		{"required without ca", NewClientTLS("", "", "", "required"), "--ssl-mode=REQUIRED"},
+		{"client certificate", NewClientTLS("/etc/mysql/ca.pem", "/etc/mysql/client.pem", "/etc/mysql/client.key", "VERIFY_CA"), "--ssl-mode=VERIFY_CA --ssl-ca='/etc/mysql/ca.pem' --ssl-cert='/etc/mysql/client.pem' --ssl-key='/etc/mysql/client.key'"},
+		{"quoted paths", NewClientTLS("/etc/my sql/it's ca.pem", "", "", "VERIFY_CA"), `--ssl-mode=VERIFY_CA --ssl-ca='/etc/my sql/it'\''s ca.pem'`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if validate_errors := test.client_tls.Validate(); validate_errors != nil {
+				t.Fatalf("Validate returned errors: %s", validate_errors)
+			}
+
+			if test.client_tls.GetCommandOptions() != test.want {
+				t.Errorf("got %q, want %q", test.client_tls.GetCommandOptions(), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package db_installer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ColumnPrivilege grants privileges on some columns of a single table, for example
+// SELECT (customer_id, created_at) on customers keeps the other customer columns out of reach
+type ColumnPrivilege struct {
+	GetTable      func() string
+	GetPrivileges func() []string
+	GetColumns    func() []string
+	GetGrant      func(database_name string) string
+	Validate      func() []error
+}
+
+func getColumnLevelPrivileges() []string {
+	return []string{"INSERT", "REFERENCES", "SELECT", "UPDATE"}
+}
+
+func NewColumnPrivilege(table string, privileges []string, columns []string) *ColumnPrivilege {
+	column_privileges := normalizePrivileges(privileges)
+
+	getGrant := func(database_name string) string {
+		var grant_privileges []string
+		for _, privilege := range column_privileges {
+			grant_privileges = append(grant_privileges, privilege+" ("+strings.Join(columns, ", ")+")")
+		}
+		return strings.Join(grant_privileges, ", ") + " ON " + database_name + "." + table
+	}
+
+	validate := func() []error {
+		var errors []error
+		if len(column_privileges) == 0 {
+			errors = append(errors, fmt.Errorf("column privilege on table: %s has no privileges", table))
+		}
+
+		if len(columns) == 0 {
+			errors = append(errors, fmt.Errorf("column privilege on table: %s has no columns", table))
+		}
+
+		for _, privilege := range column_privileges {
+			if !containsString(getColumnLevelPrivileges(), privilege) {
+				errors = append(errors, fmt.Errorf("column privilege on table: %s privilege: %s is not supported on columns, use one of %s", table, privilege, strings.Join(getColumnLevelPrivileges(), ", ")))
+			}
+		}
+
+		if len(errors) > 0 {
+			return errors
+		}
+
+		return nil
+	}
+
+	return &ColumnPrivilege{
+		GetTable: func() string {
+			return table
+		},
+		GetPrivileges: func() []string {
+			return column_privileges
+		},
+		GetColumns: func() []string {
+			return columns
+		},
+		GetGrant: func(database_name string) string {
+			return getGrant(database_name)
+		},
+		Validate: func() []error {
+			return validate()
+		},
+	}
+}
@@ -0,0 +1,307 @@
+package db_installer
+
+func ACTION_CREATE() string {
+	return "create"
+}
+
+func ACTION_SKIP() string {
+	return "skip"
+}
+
+func ACTION_UPDATE_PASSWORD() string {
+	return "update-password"
+}
+
+func ACTION_GRANT() string {
+	return "grant"
+}
+
+func ACTION_REVOKE() string {
+	return "revoke"
+}
+
+func ACTION_REPORT() string {
+	return "report"
+}
+
+func DRIFT_EXTRA() string {
+	return "extra"
+}
+
+func DRIFT_MISSING() string {
+	return "missing"
+}
+
+func ACTION_WRITE_FILE() string {
+	return "write-file"
+}
+
+func ACTION_SET() string {
+	return "set"
+}
+
+func OBJECT_TYPE_DATABASE() string {
+	return "database"
+}
+
+func OBJECT_TYPE_USER() string {
+	return "user"
+}
+
+func OBJECT_TYPE_GRANT() string {
+	return "grant"
+}
+
+func OBJECT_TYPE_FILE() string {
+	return "file"
+}
+
+func OBJECT_TYPE_GLOBAL_SETTING() string {
+	return "global_setting"
+}
+
+func OBJECT_TYPE_TABLE() string {
+	return "table"
+}
+
+func OBJECT_TYPE_RECORD() string {
+	return "record"
+}
+
+func OBJECT_TYPE_ROLE() string {
+	return "role"
+}
+
+func ACTION_DROP() string {
+	return "drop"
+}
+
+func ACTION_REMOVE_FILE() string {
+	return "remove-file"
+}
+
+func ENV_HOLISTIC_DATABASE_WRITE_POOL_SIZE() string {
+	return "HOLISTIC_DATABASE_WRITE_POOL_SIZE"
+}
+
+func ENV_HOLISTIC_DATABASE_READ_POOL_SIZE() string {
+	return "HOLISTIC_DATABASE_READ_POOL_SIZE"
+}
+
+func POOL_SIZE_DEFAULT() int {
+	return 100
+}
+
+func POOL_SIZE_NONE() int {
+	return -1
+}
+
+func POOL_SIZE_MINIMUM() int {
+	return 1
+}
+
+func POOL_SIZE_MAXIMUM() int {
+	return 1000
+}
+
+func ENV_HOLISTIC_DATABASE_CONCURRENCY() string {
+	return "HOLISTIC_DATABASE_CONCURRENCY"
+}
+
+// CONCURRENCY_DEFAULT is how many users are provisioned at the same time, every worker runs its own mysql client
+func CONCURRENCY_DEFAULT() int {
+	return 8
+}
+
+func CONCURRENCY_MINIMUM() int {
+	return 1
+}
+
+func CONCURRENCY_MAXIMUM() int {
+	return 64
+}
+
+// USER_BATCH_SIZE_MAXIMUM is how many users of a role install changes with one script at most
+func USER_BATCH_SIZE_MAXIMUM() int {
+	return 50
+}
+
+// CONFIG_VERSION is the version of the installer config file this installer reads
+func CONFIG_VERSION() int {
+	return 1
+}
+
+func ROLE_ROOT() string {
+	return "root"
+}
+
+func ROLE_MIGRATION() string {
+	return "migration"
+}
+
+func ROLE_WRITE() string {
+	return "write"
+}
+
+func ROLE_READ() string {
+	return "read"
+}
+
+// MYSQL_ROLE_HOST is the host part of the mysql role of every role, a role is granted to accounts on any host
+func MYSQL_ROLE_HOST() string {
+	return "%"
+}
+
+func MYSQL_USERNAME_LENGTH_MAXIMUM() int {
+	return 32
+}
+
+func ENV_HOLISTIC_DATABASE_USERNAME() string {
+	return "HOLISTIC_DATABASE_USERNAME"
+}
+
+func ENV_HOLISTIC_DATABASE_PASSWORD() string {
+	return "HOLISTIC_DATABASE_PASSWORD"
+}
+
+func ENV_HOLISTIC_DATABASE_CREDENTIAL_SINKS() string {
+	return "HOLISTIC_DATABASE_CREDENTIAL_SINKS"
+}
+
+func AUTHENTICATION_PLUGIN_CACHING_SHA2_PASSWORD() string {
+	return "caching_sha2_password"
+}
+
+func AUTHENTICATION_PLUGIN_MYSQL_NATIVE_PASSWORD() string {
+	return "mysql_native_password"
+}
+
+func AUTHENTICATION_PLUGIN_AUTH_SOCKET() string {
+	return "auth_socket"
+}
+
+func PASSWORD_LENGTH_DEFAULT() int {
+	return 32
+}
+
+func PASSWORD_LENGTH_MINIMUM() int {
+	return 16
+}
+
+func PASSWORD_LENGTH_MAXIMUM() int {
+	return 256
+}
+
+// PASSWORD_ALPHABET_DEFAULT has special characters for validate_password but none that need quoting
+func PASSWORD_ALPHABET_DEFAULT() string {
+	return "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.+"
+}
+
+func PASSWORD_GENERATION_ATTEMPTS() int {
+	return 100
+}
+
+func ACCOUNT_OPTION_UNSET() int {
+	return -1
+}
+
+func PASSWORD_LOCK_TIME_UNBOUNDED() int {
+	return -2
+}
+
+func TLS_MODE_DEFAULT() string {
+	return "VERIFY_IDENTITY"
+}
+
+func ENV_HOLISTIC_DATABASE_TLS_CA() string {
+	return "HOLISTIC_DATABASE_TLS_CA"
+}
+
+func ENV_HOLISTIC_DATABASE_TLS_CERT() string {
+	return "HOLISTIC_DATABASE_TLS_CERT"
+}
+
+func ENV_HOLISTIC_DATABASE_TLS_KEY() string {
+	return "HOLISTIC_DATABASE_TLS_KEY"
+}
+
+func ENV_HOLISTIC_DATABASE_TLS_MODE() string {
+	return "HOLISTIC_DATABASE_TLS_MODE"
+}
+
+func LOG_FORMAT_TEXT() string {
+	return "text"
+}
+
+func LOG_FORMAT_JSON() string {
+	return "json"
+}
+
+func LOG_REDACTED() string {
+	return "[REDACTED]"
+}
+
+func LOG_KEY_STEP() string {
+	return "step"
+}
+
+func LOG_KEY_DATABASE() string {
+	return "database"
+}
+
+func LOG_KEY_ROLE() string {
+	return "role"
+}
+
+func LOG_KEY_USER() string {
+	return "user"
+}
+
+func LOG_KEY_HOST() string {
+	return "host"
+}
+
+func LOG_KEY_HOST_USER() string {
+	return "host_user"
+}
+
+func LOG_KEY_SINK() string {
+	return "sink"
+}
+
+func LOG_KEY_PATH() string {
+	return "path"
+}
+
+func STATUS_OK() string {
+	return "ok"
+}
+
+func STATUS_MISSING() string {
+	return "missing"
+}
+
+func STATUS_UNEXPECTED() string {
+	return "unexpected"
+}
+
+func STATUS_DIFFERENT() string {
+	return "different"
+}
+
+func LOGIN_OK() string {
+	return "ok"
+}
+
+func LOGIN_FAILED() string {
+	return "failed"
+}
+
+func LOGIN_SKIPPED() string {
+	return "skipped"
+}
+
+// CREDENTIAL_PASSWORD_PLACEHOLDER only uses characters no credential format escapes
+func CREDENTIAL_PASSWORD_PLACEHOLDER() string {
+	return "HOLISTICPASSWORDPLACEHOLDER"
+}
@@ -0,0 +1,131 @@
+package db_installer
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// getCredentialWithPassword is the same credential with another password, verify renders files with a placeholder
+// as the password a file should hold is never known outside install and rotate
+func getCredentialWithPassword(credential DatabaseCredential, password string) DatabaseCredential {
+	return newDatabaseCredential(credential.GetHostUsername(), credential.GetHostName(), credential.GetPortNumber(), credential.GetDatabaseName(), credential.GetUsername(), password, credential.GetClientTLS(), credential.GetAuthenticationPlugin())
+}
+
+// snapshotCredentialFile keeps the content, mode and owner of a credential file so a failed install can put it back,
+// a file that did not exist yet is removed again
+func snapshotCredentialFile(path string) (func() []error, []error) {
+	var errors []error
+	info, stat_error := os.Stat(path)
+	if os.IsNotExist(stat_error) {
+		return func() []error {
+			var errors []error
+			remove_error := os.Remove(path)
+			if remove_error != nil && !os.IsNotExist(remove_error) {
+				errors = append(errors, remove_error)
+				return errors
+			}
+			return nil
+		}, nil
+	} else if stat_error != nil {
+		errors = append(errors, stat_error)
+		return nil, errors
+	}
+
+	content, read_error := os.ReadFile(path)
+	if read_error != nil {
+		errors = append(errors, read_error)
+		return nil, errors
+	}
+
+	return func() []error {
+		var errors []error
+		write_error := os.WriteFile(path, content, info.Mode().Perm())
+		if write_error != nil {
+			errors = append(errors, write_error)
+			return errors
+		}
+
+		chmod_error := os.Chmod(path, info.Mode().Perm())
+		if chmod_error != nil {
+			errors = append(errors, chmod_error)
+			return errors
+		}
+
+		if stat, is_stat := info.Sys().(*syscall.Stat_t); is_stat {
+			chown_error := os.Chown(path, int(stat.Uid), int(stat.Gid))
+			if chown_error != nil {
+				errors = append(errors, chown_error)
+				return errors
+			}
+		}
+		return nil
+	}, nil
+}
+
+// verifyCredentialFile compares a credential file with what getContent renders for the credential, the password
+// only has to be the same wherever the file repeats it. the file must not be readable by other users and
+// must belong to owner unless owner is empty
+func verifyCredentialFile(path string, owner string, credential DatabaseCredential, getContent func(credential DatabaseCredential) (string, []error)) ([]string, []error) {
+	var errors []error
+	var problems []string
+	info, stat_error := os.Stat(path)
+	if stat_error != nil {
+		errors = append(errors, stat_error)
+		return nil, errors
+	}
+
+	if info.Mode().Perm()&0077 != 0 {
+		problems = append(problems, fmt.Sprintf("mode %04o lets other users read it, expected 0600", info.Mode().Perm()))
+	}
+
+	if owner != "" {
+		owner_user, owner_user_error := user.Lookup(owner)
+		if owner_user_error != nil {
+			errors = append(errors, owner_user_error)
+			return nil, errors
+		}
+
+		if stat, is_stat := info.Sys().(*syscall.Stat_t); is_stat && strconv.FormatUint(uint64(stat.Uid), 10) != owner_user.Uid {
+			problems = append(problems, fmt.Sprintf("owned by uid %d, expected %s", stat.Uid, owner))
+		}
+	}
+
+	content, read_error := os.ReadFile(path)
+	if read_error != nil {
+		errors = append(errors, read_error)
+		return nil, errors
+	}
+
+	expected_content, expected_content_errors := getContent(getCredentialWithPassword(credential, CREDENTIAL_PASSWORD_PLACEHOLDER()))
+	if expected_content_errors != nil {
+		return nil, expected_content_errors
+	}
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for index, part := range strings.Split(expected_content, CREDENTIAL_PASSWORD_PLACEHOLDER()) {
+		if index > 0 {
+			pattern.WriteString("([^\n]+)")
+		}
+		pattern.WriteString(regexp.QuoteMeta(part))
+	}
+	pattern.WriteString("$")
+
+	matches := regexp.MustCompile(pattern.String()).FindStringSubmatch(string(content))
+	if matches == nil {
+		problems = append(problems, "content differs from what install writes")
+	} else {
+		for _, password := range matches[1:] {
+			if password != matches[1] {
+				problems = append(problems, "content holds more than one password")
+				break
+			}
+		}
+	}
+	return problems, nil
+}
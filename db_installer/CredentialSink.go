@@ -0,0 +1,237 @@
+package db_installer
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	host_client "github.com/matehaxor03/holistic_host_client/host_client"
+)
+
+// CredentialSink is where the installer delivers the credentials it generates, GetLocation
+// describes where Write would put a credential so plans can show it without writing anything.
+// Verify returns how a delivered credential differs from what Write delivers, the password aside,
+// and Snapshot returns how to put a credential back the way it is now when an install fails
+type CredentialSink struct {
+	GetName     func() string
+	GetLocation func(credential DatabaseCredential) (string, []error)
+	Exists      func(credential DatabaseCredential) (bool, []error)
+	Write       func(credential DatabaseCredential) []error
+	Remove      func(credential DatabaseCredential) []error
+	Verify      func(credential DatabaseCredential) ([]string, []error)
+	Snapshot    func(credential DatabaseCredential) (func() []error, []error)
+}
+
+// writeCredentialSinks writes every credential to every sink and keeps what each sink held before in install_journal,
+// install_report and install_journal may be nil
+func writeCredentialSinks(credential_sinks []CredentialSink, credentials []DatabaseCredential, install_report *InstallReport, install_journal *InstallJournal, logger *slog.Logger) []error {
+	for _, credential := range credentials {
+		for _, credential_sink := range credential_sinks {
+			location, location_errors := credential_sink.GetLocation(credential)
+			if location_errors != nil {
+				return location_errors
+			}
+
+			if install_journal != nil {
+				restore, snapshot_errors := credential_sink.Snapshot(credential)
+				if snapshot_errors != nil {
+					return snapshot_errors
+				}
+				install_journal.Record("write "+credential_sink.GetName()+" "+location, restore)
+			}
+
+			write_errors := credential_sink.Write(credential)
+			if write_errors != nil {
+				return write_errors
+			}
+			logger.Debug("wrote credential", LOG_KEY_USER(), credential.GetUsername(), LOG_KEY_HOST_USER(), credential.GetHostUsername(), LOG_KEY_SINK(), credential_sink.GetName(), LOG_KEY_PATH(), location)
+
+			if install_report != nil {
+				install_report.AddFile(credential_sink.GetName(), location, credential.GetHostUsername(), "written")
+			}
+		}
+	}
+	return nil
+}
+
+func getAbsoluteDirectory(host_client_instance *host_client.HostClient, directory string) (*host_client.AbsoluteDirectory, []error) {
+	var errors []error
+	if !strings.HasPrefix(directory, "/") {
+		errors = append(errors, fmt.Errorf("directory: %s must be an absolute path", directory))
+		return nil, errors
+	}
+
+	var path []string
+	for _, directory_part := range strings.Split(directory, "/") {
+		if directory_part != "" {
+			path = append(path, directory_part)
+		}
+	}
+	return host_client_instance.AbsoluteDirectory(path)
+}
+
+// newDirectoryCredentialSink writes one file per credential into directory, getContent renders the file
+func newDirectoryCredentialSink(name string, directory string, extension string, getContent func(credential DatabaseCredential) (string, []error)) (*CredentialSink, []error) {
+	host_client_instance, host_client_errors := host_client.NewHostClient()
+	if host_client_errors != nil {
+		return nil, host_client_errors
+	}
+
+	sink_directory, sink_directory_errors := getAbsoluteDirectory(host_client_instance, directory)
+	if sink_directory_errors != nil {
+		return nil, sink_directory_errors
+	}
+
+	getFile := func(credential DatabaseCredential) (*host_client.AbsoluteFile, []error) {
+		return host_client_instance.AbsoluteFile(*sink_directory, credential.GetName()+extension)
+	}
+
+	return &CredentialSink{
+		GetName: func() string {
+			return name
+		},
+		GetLocation: func(credential DatabaseCredential) (string, []error) {
+			file, file_errors := getFile(credential)
+			if file_errors != nil {
+				return "", file_errors
+			}
+			return file.GetPathAsString(), nil
+		},
+		Exists: func(credential DatabaseCredential) (bool, []error) {
+			file, file_errors := getFile(credential)
+			if file_errors != nil {
+				return false, file_errors
+			}
+			return file.Exists(), nil
+		},
+		Write: func(credential DatabaseCredential) []error {
+			var errors []error
+			content, content_errors := getContent(credential)
+			if content_errors != nil {
+				return content_errors
+			}
+
+			create_directory_errors := sink_directory.CreateIfDoesNotExist()
+			if create_directory_errors != nil {
+				return create_directory_errors
+			}
+
+			file, file_errors := getFile(credential)
+			if file_errors != nil {
+				return file_errors
+			}
+
+			remove_file_errors := file.RemoveIfExists()
+			if remove_file_errors != nil {
+				return remove_file_errors
+			}
+
+			create_file_errors := file.Create()
+			if create_file_errors != nil {
+				return create_file_errors
+			}
+
+			append_errors := file.Append(content)
+			if append_errors != nil {
+				return append_errors
+			}
+
+			chmod_error := os.Chmod(file.GetPathAsString(), 0600)
+			if chmod_error != nil {
+				errors = append(errors, chmod_error)
+				return errors
+			}
+			return nil
+		},
+		Remove: func(credential DatabaseCredential) []error {
+			file, file_errors := getFile(credential)
+			if file_errors != nil {
+				return file_errors
+			}
+			return file.RemoveIfExists()
+		},
+		Verify: func(credential DatabaseCredential) ([]string, []error) {
+			file, file_errors := getFile(credential)
+			if file_errors != nil {
+				return nil, file_errors
+			}
+			return verifyCredentialFile(file.GetPathAsString(), "", credential, getContent)
+		},
+		Snapshot: func(credential DatabaseCredential) (func() []error, []error) {
+			file, file_errors := getFile(credential)
+			if file_errors != nil {
+				return nil, file_errors
+			}
+			return snapshotCredentialFile(file.GetPathAsString())
+		},
+	}, nil
+}
+
+// NewCredentialSink builds a built-in sink: option-file, dotenv, json, yaml, kubernetes-secret or secret-store-file,
+// location is the directory (or the file for secret-store-file) and namespace is only used by kubernetes-secret
+func NewCredentialSink(sink_type string, location string, namespace string) (*CredentialSink, []error) {
+	var errors []error
+	if sink_type != "option-file" && location == "" {
+		errors = append(errors, fmt.Errorf("credential sink: %s requires a location", sink_type))
+		return nil, errors
+	}
+
+	switch sink_type {
+	case "option-file":
+		return NewOptionFileCredentialSink()
+	case "dotenv":
+		return NewDotenvCredentialSink(location)
+	case "json":
+		return NewJSONSecretsCredentialSink(location)
+	case "yaml":
+		return NewYAMLSecretsCredentialSink(location)
+	case "kubernetes-secret":
+		return NewKubernetesSecretCredentialSink(location, namespace)
+	case "secret-store-file":
+		index := strings.LastIndex(location, "/")
+		if index <= 0 || index == len(location)-1 {
+			errors = append(errors, fmt.Errorf("credential sink: %s location: %s must be an absolute file path", sink_type, location))
+			return nil, errors
+		}
+
+		secret_store_client, secret_store_client_errors := NewFileSecretStoreClient(location[:index], location[index+1:])
+		if secret_store_client_errors != nil {
+			return nil, secret_store_client_errors
+		}
+		return NewSecretStoreCredentialSink(*secret_store_client, "")
+	}
+
+	errors = append(errors, fmt.Errorf("unknown credential sink: %s", sink_type))
+	return nil, errors
+}
+
+// ParseCredentialSinks reads a comma separated list of type[:location[:namespace]] such as option-file,dotenv:/etc/holistic
+func ParseCredentialSinks(raw_credential_sinks string) ([]CredentialSink, []error) {
+	var errors []error
+	var credential_sinks []CredentialSink
+	for _, raw_credential_sink := range strings.Split(raw_credential_sinks, ",") {
+		raw_credential_sink = strings.TrimSpace(raw_credential_sink)
+		if raw_credential_sink == "" {
+			continue
+		}
+
+		parts := strings.SplitN(raw_credential_sink, ":", 3)
+		for len(parts) < 3 {
+			parts = append(parts, "")
+		}
+
+		credential_sink, credential_sink_errors := NewCredentialSink(parts[0], parts[1], parts[2])
+		if credential_sink_errors != nil {
+			errors = append(errors, credential_sink_errors...)
+			continue
+		}
+		credential_sinks = append(credential_sinks, *credential_sink)
+	}
+
+	if len(errors) > 0 {
+		return nil, errors
+	}
+
+	return credential_sinks, nil
+}
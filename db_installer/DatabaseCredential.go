@@ -0,0 +1,50 @@
+package db_installer
+
+type DatabaseCredential struct {
+	GetHostUsername func() string
+	GetHostName     func() string
+	GetPortNumber   func() string
+	GetDatabaseName func() string
+	GetUsername     func() string
+	GetPassword     func() string
+	GetClientTLS    func() *ClientTLS
+	// HasPassword is false for auth_socket users, the operating system user is their credential
+	HasPassword             func() bool
+	GetAuthenticationPlugin func() string
+	GetName                 func() string
+}
+
+func newDatabaseCredential(host_username string, host_name string, port_number string, database_name string, username string, password string, client_tls *ClientTLS, authentication_plugin string) DatabaseCredential {
+	return DatabaseCredential{
+		GetHostUsername: func() string {
+			return host_username
+		},
+		GetHostName: func() string {
+			return host_name
+		},
+		GetPortNumber: func() string {
+			return port_number
+		},
+		GetDatabaseName: func() string {
+			return database_name
+		},
+		GetUsername: func() string {
+			return username
+		},
+		GetPassword: func() string {
+			return password
+		},
+		GetClientTLS: func() *ClientTLS {
+			return client_tls
+		},
+		HasPassword: func() bool {
+			return authentication_plugin != AUTHENTICATION_PLUGIN_AUTH_SOCKET()
+		},
+		GetAuthenticationPlugin: func() string {
+			return authentication_plugin
+		},
+		GetName: func() string {
+			return "holistic_db_config#" + host_name + "#" + port_number + "#" + database_name + "#" + username
+		},
+	}
+}
@@ -2,31 +2,108 @@ package db_installer
 
 import (
 	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 
 	common "github.com/matehaxor03/holistic_common/common"
 	dao "github.com/matehaxor03/holistic_db_client/dao"
 	host_client "github.com/matehaxor03/holistic_host_client/host_client"
 	json "github.com/matehaxor03/holistic_json/json"
 	validate "github.com/matehaxor03/holistic_validator/validate"
-	validation_constants "github.com/matehaxor03/holistic_validator/validation_constants"
 )
 
+// databaseAccount is one user@host of a statement that changes several accounts at once, mysql roles are accounts too.
+// password is only set where the statement creates the account or changes its password
+type databaseAccount struct {
+	username  string
+	host_name string
+	password  string
+}
+
+// accountGrant is a privilege or a mysql role of an account, current and desired grants are compared by description.
+// sql grants a desired grant or revokes a current one, undo_sql does the opposite for the install journal
+type accountGrant struct {
+	description string
+	sql         string
+	undo_sql    string
+}
+
+type pooledDatabaseUser struct {
+	username   string
+	host_name  string
+	user_count int
+}
+
+type rotationTarget struct {
+	role           string
+	username       string
+	user_count     int
+	host_usernames []string
+	host_names     []string
+	client_tls     *ClientTLS
+	// authentication_plugin is auth_socket for targets without a password to rotate
+	authentication_plugin string
+}
+
 type DatabaseInstaller struct {
-	Validate func() []error
-	Install  func() []error
+	Validate                              func() []error
+	Install                               func() (*InstallReport, []error)
+	Plan                                  func() (*InstallPlan, []error)
+	Uninstall                             func(confirm_database_name string, keep_data bool) []error
+	WriteCredentials                      func() []error
+	RotateCredentials                     func(roles ...string) []error
+	RotateCredentialsWithRetainedPassword func(roles ...string) []error
+	DiscardOldPasswords                   func(roles ...string) []error
+	Reconcile                             func(report_only bool) (*DriftReport, []error)
+	Status                                func() (*InstallStatus, []error)
+	Resume                                func() (*InstallReport, []error)
+	ExportSQL                             func(secrets_file string) (string, []error)
+	WriteExportedCredentials              func(secrets_file string) []error
 }
 
-func NewDatabaseInstaller(database_host_name string, database_port_number string, database_name string, database_root_user string, database_root_password string, write_host_users []string, read_host_users []string, migration_host_users []string) (*DatabaseInstaller, []error) {
+func NewDatabaseInstaller(database_host_name string, database_port_number string, database_name string, database_root_user string, database_root_password string, write_host_users []string, read_host_users []string, migration_host_users []string, write_pool_size int, read_pool_size int, credential_sinks []CredentialSink, logger *slog.Logger) (*DatabaseInstaller, []error) {
+	config := NewInstallerConfig()
+	config.SetDatabaseHostName(database_host_name)
+	config.SetDatabasePortNumber(database_port_number)
+	config.SetDatabaseName(database_name)
+	config.SetDatabaseRootUsername(database_root_user)
+	config.SetDatabaseRootPassword(database_root_password)
+	config.GetRole(ROLE_WRITE()).SetHostUsers(write_host_users)
+	config.GetRole(ROLE_READ()).SetHostUsers(read_host_users)
+	config.GetRole(ROLE_MIGRATION()).SetHostUsers(migration_host_users)
+	config.GetRole(ROLE_WRITE()).SetPoolSize(write_pool_size)
+	config.GetRole(ROLE_READ()).SetPoolSize(read_pool_size)
+	config.SetCredentialSinks(credential_sinks)
+	return NewDatabaseInstallerFromConfig(config, logger)
+}
+
+func NewDatabaseInstallerFromConfig(config *InstallerConfig, logger *slog.Logger) (*DatabaseInstaller, []error) {
 	verify := validate.NewValidator()
-	db_host_name := database_host_name
-	db_port_number := database_port_number
-	db_name := database_name
-	database_username := database_root_user
-	database_password := database_root_password
+	db_host_name := config.GetDatabaseHostName()
+	db_port_number := config.GetDatabasePortNumber()
+	db_name := config.GetDatabaseName()
+	database_username := config.GetDatabaseRootUsername()
+	database_password := config.GetDatabaseRootPassword()
+	roles := config.GetRoles()
+	credential_sinks := config.GetCredentialSinks()
+
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+	}
+	logger = slog.New(newRedactingLogHandler(logger.Handler())).With(LOG_KEY_DATABASE(), db_name)
+
+	// the db client reads the root option files from the ~/.db of the host user running the installer, so root is
+	// always written as option files and never through the configured sinks that hand credentials to applications
+	root_credential_sink, root_credential_sink_errors := NewOptionFileCredentialSink()
+	if root_credential_sink_errors != nil {
+		return nil, root_credential_sink_errors
+	}
 
-	host_client_instance, host_client_errors := host_client.NewHostClient()
-	if host_client_errors != nil {
-		return nil, host_client_errors
+	if len(credential_sinks) == 0 {
+		credential_sinks = append(credential_sinks, *root_credential_sink)
 	}
 
 	getDatabaseHostName := func() string {
@@ -49,140 +126,222 @@ func NewDatabaseInstaller(database_host_name string, database_port_number string
 		return database_password
 	}
 
-	writeCredentialsFile := func(host_usernames []string, host_name string, port_number string, database_name string, username string, password string, user_count int) []error {
-		var errors []error
+	getRoles := func() []*RoleDefinition {
+		return roles
+	}
+
+	getRole := func(name string) *RoleDefinition {
+		for _, role := range getRoles() {
+			if role.GetName() == name {
+				return role
+			}
+		}
+		return nil
+	}
+
+	getCredentialSinks := func() []CredentialSink {
+		return credential_sinks
+	}
+
+	getRootCredentialSinks := func() []CredentialSink {
+		return []CredentialSink{*root_credential_sink}
+	}
 
-		user_count_as_string := ""
-		if user_count != -1 {
-			user_count_as_string = fmt.Sprintf("%d", user_count)
+	getStepLogger := func(step string) *slog.Logger {
+		return logger.With(LOG_KEY_STEP(), step)
+	}
+
+	getUserCountAsString := func(user_count int) string {
+		if user_count == -1 {
+			return ""
+		}
+		return fmt.Sprintf("%d", user_count)
+	}
+
+	// getRoleClientTLS is written into the option files of a role, the installer's own certificate is never handed out
+	getRoleClientTLS := func(role *RoleDefinition) *ClientTLS {
+		if role.GetClientTLS() != nil {
+			return role.GetClientTLS()
+		}
+
+		if config.GetClientTLS() == nil {
+			return nil
 		}
+		return NewClientTLS(config.GetClientTLS().GetCA(), "", "", config.GetClientTLS().GetMode())
+	}
 
+	getCredentials := func(host_usernames []string, host_name string, port_number string, database_name string, username string, password string, user_count int, client_tls *ClientTLS, authentication_plugin string) []DatabaseCredential {
+		var credentials []DatabaseCredential
 		for _, host_username := range host_usernames {
-			fmt.Println(host_username)
-			host_user, host_user_errors := host_client_instance.User(host_username)
-			if host_user_errors != nil {
-				return host_user_errors
-			}
+			credentials = append(credentials, newDatabaseCredential(host_username, host_name, port_number, database_name, username+getUserCountAsString(user_count), password, client_tls, authentication_plugin))
+		}
+		return credentials
+	}
 
-			host_home_directory, host_home_directory_errors := host_user.GetHomeDirectoryAbsoluteDirectory()
-			if host_home_directory_errors != nil {
-				return host_home_directory_errors
-			}
+	// writeCredentials keeps what each sink held before in install_journal, install_report and install_journal may be nil
+	writeCredentials := func(credential_sinks []CredentialSink, install_report *InstallReport, install_journal *InstallJournal, step_logger *slog.Logger, host_usernames []string, host_name string, port_number string, database_name string, username string, password string, user_count int, client_tls *ClientTLS, authentication_plugin string) []error {
+		return writeCredentialSinks(credential_sinks, getCredentials(host_usernames, host_name, port_number, database_name, username, password, user_count, client_tls, authentication_plugin), install_report, install_journal, step_logger)
+	}
 
-			var db_creds_directory_path []string
-			db_creds_directory_path = append(db_creds_directory_path, host_home_directory.GetPath()...)
-			db_creds_directory_path = append(db_creds_directory_path, ".db")
+	getSQLCommand := func(client *dao.Client) (*SQLCommand, []error) {
+		return newSQLCommand(client.GetHostClientUser(), getDatabaseHostName(), getDatabasePortNumber(), getDatabaseRootUsername(), config.GetClientTLS())
+	}
 
-			db_creds_directory, db_creds_directory_errors := host_client_instance.AbsoluteDirectory(db_creds_directory_path)
-			if db_creds_directory_errors != nil {
-				return db_creds_directory_errors
+	// getWorkerPool runs every worker as the host user of client, each with a host user instance of its own
+	getWorkerPool := func(client *dao.Client) *WorkerPool {
+		return newWorkerPool(config.GetConcurrency(), func() (*SQLCommand, []error) {
+			host_client_instance, host_client_errors := host_client.NewHostClient()
+			if host_client_errors != nil {
+				return nil, host_client_errors
 			}
 
-			db_creds_directory_create_errors := db_creds_directory.CreateIfDoesNotExist()
-			if db_creds_directory_create_errors != nil {
-				return db_creds_directory_create_errors
+			host_client_user, host_client_user_errors := host_client_instance.User(client.GetHostClientUser().GetUsername())
+			if host_client_user_errors != nil {
+				return nil, host_client_user_errors
 			}
+			return newSQLCommand(*host_client_user, getDatabaseHostName(), getDatabasePortNumber(), getDatabaseRootUsername(), config.GetClientTLS())
+		})
+	}
 
-			db_creds_file, db_creds_file_errors := host_client_instance.AbsoluteFile(*db_creds_directory, "holistic_db_config#"+host_name+"#"+port_number+"#"+database_name+"#"+username+user_count_as_string+".config")
-			if db_creds_file_errors != nil {
-				return db_creds_file_errors
-			}
+	getPooledUsersOutsidePool := func(sql_command *SQLCommand, username_prefix string, pool_size int) ([]pooledDatabaseUser, []error) {
+		var pooled_users []pooledDatabaseUser
+		select_users_sql, select_users_sql_errors := getSelectUsersByPrefixSQL(username_prefix)
+		if select_users_sql_errors != nil {
+			return nil, select_users_sql_errors
+		}
+
+		var sql_builder strings.Builder
+		sql_builder.WriteString(select_users_sql)
+		records, records_errors := sql_command.ExecuteUnsafeCommand(sql_builder, json.NewMapValue())
+		if records_errors != nil {
+			return nil, records_errors
+		}
 
-			remove_db_file_if_exists_errors := db_creds_file.RemoveIfExists()
-			if remove_db_file_if_exists_errors != nil {
-				return remove_db_file_if_exists_errors
+		for index := 0; index < records.Len(); index++ {
+			record, record_errors := records.GetMap(index)
+			if record_errors != nil {
+				return nil, record_errors
 			}
 
-			create_file_errors := db_creds_file.Create()
-			if create_file_errors != nil {
-				return create_file_errors
+			username, username_errors := record.GetStringValue("User")
+			if username_errors != nil {
+				return nil, username_errors
 			}
 
-			db_creds_file_append_errors := db_creds_file.Append("[client]\n" + "user=" + (username + user_count_as_string) + "\npassword=" + password + "\n[mysqld]\nskip-log-bin")
-			if db_creds_file_append_errors != nil {
-				return db_creds_file_append_errors
+			host_name, host_name_errors := record.GetStringValue("Host")
+			if host_name_errors != nil {
+				return nil, host_name_errors
 			}
 
-			user_primary_group, user_primary_group_errors := host_user.GetPrimaryGroup()
-			if user_primary_group_errors != nil {
-				return user_primary_group_errors
-			} else if user_primary_group == nil {
-				errors = append(errors, fmt.Errorf("primary group is nil"))
-				return errors
+			user_count, user_count_error := strconv.Atoi(strings.TrimPrefix(username, username_prefix))
+			if user_count_error != nil || user_count < 0 {
+				continue
 			}
 
-			set_owner_errors := db_creds_file.SetOwner(*host_user, *user_primary_group)
-			if set_owner_errors != nil {
-				return set_owner_errors
+			if user_count >= pool_size {
+				pooled_users = append(pooled_users, pooledDatabaseUser{username: username, host_name: host_name, user_count: user_count})
 			}
+		}
+
+		return pooled_users, nil
+	}
 
-			set_directory_owner_errors := db_creds_directory.SetOwner(*host_user, *user_primary_group)
-			if set_directory_owner_errors != nil {
-				return set_directory_owner_errors
+	removeCredentials := func(credential_sinks []CredentialSink, install_report *InstallReport, step_logger *slog.Logger, host_usernames []string, host_name string, port_number string, database_name string, username string, user_count int) []error {
+		for _, credential := range getCredentials(host_usernames, host_name, port_number, database_name, username, "", user_count, nil, "") {
+			for _, credential_sink := range credential_sinks {
+				remove_errors := credential_sink.Remove(credential)
+				if remove_errors != nil {
+					return remove_errors
+				}
+
+				location, location_errors := credential_sink.GetLocation(credential)
+				if location_errors != nil {
+					return location_errors
+				}
+				step_logger.Debug("removed credential", LOG_KEY_USER(), credential.GetUsername(), LOG_KEY_HOST_USER(), credential.GetHostUsername(), LOG_KEY_SINK(), credential_sink.GetName(), LOG_KEY_PATH(), location)
+
+				if install_report != nil {
+					install_report.AddFile(credential_sink.GetName(), location, credential.GetHostUsername(), "removed")
+				}
 			}
 		}
 		return nil
 	}
 
-	install := func() []error {
-		directory_parts := common.GetDataDirectory()
-		directory := "/"
-		for index, directory_part := range directory_parts {
-			directory += directory_part
-			if index < len(directory_parts)-1 {
-				directory += "/"
-			}
+	shrinkPool := func(install_report *InstallReport, step_logger *slog.Logger, role string, sql_command *SQLCommand, host_usernames []string, username_prefix string, pool_size int) []error {
+		pooled_users, pooled_users_errors := getPooledUsersOutsidePool(sql_command, username_prefix, pool_size)
+		if pooled_users_errors != nil {
+			return pooled_users_errors
 		}
 
-		var errors []error
-		db_hostname := getDatabaseHostName()
-		db_port_number := getDatabasePortNumber()
-		db_name := getDatabaseName()
-		root_db_username := getDatabaseRootUsername()
-		root_db_password := getDatabaseRootPassword()
-		migration_db_username := common.CONSTANT_HOLISTIC_DATABASE_MIGRATION_USERNAME()
-		migration_db_password := common.GenerateGuid()
+		for _, pooled_user := range pooled_users {
+			drop_user_sql, drop_user_sql_errors := getDropUserSQL(pooled_user.username, pooled_user.host_name)
+			if drop_user_sql_errors != nil {
+				return drop_user_sql_errors
+			}
 
-		write_db_username := common.CONSTANT_HOLISTIC_DATABASE_WRITE_USERNAME()
-		write_db_password := common.GenerateGuid()
+			var sql_builder strings.Builder
+			sql_builder.WriteString(drop_user_sql)
+			options := json.NewMapValue()
+			options.SetBoolValue("read_no_records", true)
+			_, drop_user_errors := sql_command.ExecuteUnsafeCommand(sql_builder, options)
+			if drop_user_errors != nil {
+				return drop_user_errors
+			}
+			step_logger.Info("dropped user outside the pool", LOG_KEY_ROLE(), role, LOG_KEY_USER(), pooled_user.username, LOG_KEY_HOST(), pooled_user.host_name)
+			install_report.AddUser(role, getAccountName(pooled_user.username, pooled_user.host_name), "dropped")
 
-		read_db_username := common.CONSTANT_HOLISTIC_DATABASE_READ_USERNAME()
-		read_db_password := common.GenerateGuid()
+			remove_files_errors := removeCredentials(getCredentialSinks(), install_report, step_logger, host_usernames, getDatabaseHostName(), getDatabasePortNumber(), getDatabaseName(), username_prefix, pooled_user.user_count)
+			if remove_files_errors != nil {
+				return remove_files_errors
+			}
+		}
+		return nil
+	}
 
+	getAllHostUsers := func() []string {
 		var all_host_users []string
-		all_host_users = append(all_host_users, write_host_users...)
-		all_host_users = append(all_host_users, read_host_users...)
-		all_host_users = append(all_host_users, migration_host_users...)
-
-		root_errors := writeCredentialsFile(all_host_users, db_hostname, db_port_number, "", root_db_username, root_db_password, -1)
-		if root_errors != nil {
-			return root_errors
+		for _, role := range getRoles() {
+			all_host_users = append(all_host_users, role.GetHostUsers()...)
 		}
+		return all_host_users
+	}
 
-		root_errors2 := writeCredentialsFile(all_host_users, db_hostname, db_port_number, db_name, root_db_username, root_db_password, -1)
-		if root_errors2 != nil {
-			return root_errors2
+	// validateRootPasswordSet is only checked where the root option files are written, export runs without root credentials
+	validateRootPasswordSet := func() []error {
+		var errors []error
+		if getDatabaseRootPassword() == "" {
+			errors = append(errors, fmt.Errorf("root password is empty, set %s or --root-password-file", common.ENV_HOLISTIC_DATABASE_ROOT_PASSWORD()))
+			return errors
 		}
+		return nil
+	}
 
-		root_errors3 := writeCredentialsFile(all_host_users, db_hostname, db_port_number, "mysql", root_db_username, root_db_password, -1)
-		if root_errors3 != nil {
-			return root_errors3
+	writeRootCredentialsFiles := func(install_report *InstallReport, install_journal *InstallJournal, step_logger *slog.Logger) []error {
+		root_password_errors := validateRootPasswordSet()
+		if root_password_errors != nil {
+			return root_password_errors
 		}
 
-		if len(errors) > 0 {
-			return errors
+		for _, root_database_name := range [...]string{"", getDatabaseName(), "mysql"} {
+			root_errors := writeCredentials(getRootCredentialSinks(), install_report, install_journal, step_logger, getAllHostUsers(), getDatabaseHostName(), getDatabasePortNumber(), root_database_name, getDatabaseRootUsername(), getDatabaseRootPassword(), -1, config.GetClientTLS(), "")
+			if root_errors != nil {
+				return root_errors
+			}
 		}
+		return nil
+	}
 
-		usernames := [...]string{root_db_username, migration_db_username, write_db_username, read_db_username}
-
-		usernamesGrouped := make(map[string]int)
-		for _, num := range usernames {
-			usernamesGrouped[num] = usernamesGrouped[num] + 1
-		}
+	validateUniqueDatabaseUsernames := func() []error {
+		var errors []error
+		root_db_username := getDatabaseRootUsername()
+		for _, role := range getRoles() {
+			if role.GetUsername() == root_db_username || (role.IsPooled() && strings.HasPrefix(root_db_username, role.GetUsername())) {
+				errors = append(errors, fmt.Errorf("database username: %s of the root user collides with the %s role, root and role database usernames must be all unqiue", root_db_username, role.GetName()))
+			}
 
-		for key, element := range usernamesGrouped {
-			if element > 1 {
-				errors = append(errors, fmt.Errorf("database username: %s was detected %d times - root, holistic_migration, holistic_write and holistic_read database usernames must be all unqiue", key, element))
+			if role.GetMySQLRoleName() == root_db_username {
+				errors = append(errors, fmt.Errorf("database username: %s of the root user is the mysql role of the %s role", root_db_username, role.GetName()))
 			}
 		}
 
@@ -190,318 +349,2551 @@ func NewDatabaseInstaller(database_host_name string, database_port_number string
 			return errors
 		}
 
-		client_manager, client_manager_errors := dao.NewClientManager()
-		if client_manager_errors != nil {
-			errors = append(errors, client_manager_errors...)
-		}
+		return nil
+	}
 
-		if len(errors) > 0 {
-			return errors
-		}
+	executeSQL := func(sql_command *SQLCommand, raw_sql string) []error {
+		var sql_builder strings.Builder
+		sql_builder.WriteString(raw_sql)
+		options := json.NewMapValue()
+		options.SetBoolValue("read_no_records", true)
+		_, execute_errors := sql_command.ExecuteUnsafeCommand(sql_builder, options)
+		return execute_errors
+	}
 
-		client, client_errors := client_manager.GetClient(db_hostname, db_port_number, db_name, root_db_username)
-		if client_errors != nil {
-			errors = append(errors, client_errors...)
+	// getRoleHosts is where the accounts of a role are created, the database host name unless the role lists allowed hosts
+	getRoleHosts := func(role *RoleDefinition) []string {
+		if len(role.GetAllowedHosts()) == 0 {
+			return []string{getDatabaseHostName()}
 		}
+		return role.GetAllowedHosts()
+	}
 
-		if len(errors) > 0 {
-			return errors
+	getUserHosts := func(sql_command *SQLCommand, username string) ([]string, []error) {
+		select_user_hosts_sql, select_user_hosts_sql_errors := getSelectUserHostsSQL(username)
+		if select_user_hosts_sql_errors != nil {
+			return nil, select_user_hosts_sql_errors
 		}
 
-		database_exists, database_exists_errors := client.DatabaseExists(db_name)
-		if database_exists_errors != nil {
-			return database_exists_errors
+		var sql_builder strings.Builder
+		sql_builder.WriteString(select_user_hosts_sql)
+		records, records_errors := sql_command.ExecuteUnsafeCommand(sql_builder, json.NewMapValue())
+		if records_errors != nil {
+			return nil, records_errors
 		}
 
-		if !database_exists {
-			character_set := validation_constants.GET_CHARACTER_SET_UTF8MB4()
-			collate := validation_constants.GET_COLLATE_UTF8MB4_0900_AI_CI()
+		var user_hosts []string
+		for index := 0; index < records.Len(); index++ {
+			record, record_errors := records.GetMap(index)
+			if record_errors != nil {
+				return nil, record_errors
+			}
 
-			fmt.Println("creating database...")
-			_, database_creation_errs := client.CreateDatabase(db_name, &character_set, &collate)
-			if database_creation_errs != nil {
-				errors = append(errors, database_creation_errs...)
-				return errors
+			host_name, host_name_errors := record.GetStringValue("Host")
+			if host_name_errors != nil {
+				return nil, host_name_errors
 			}
-		} else {
-			fmt.Println("(skip) database already exists...")
+			user_hosts = append(user_hosts, host_name)
 		}
+		return user_hosts, nil
+	}
 
-		database := client.GetDatabase()
-		set_root_database_username_errors := database.SetDatabaseUsername(root_db_username)
-		if set_root_database_username_errors != nil {
-			return set_root_database_username_errors
+	// getPasswordPolicy is nil when the server has no validate_password component or plugin
+	getPasswordPolicy := func(sql_command *SQLCommand) (*PasswordPolicy, []error) {
+		var sql_builder strings.Builder
+		sql_builder.WriteString(getSelectPasswordPolicySQL())
+		records, records_errors := sql_command.ExecuteUnsafeCommand(sql_builder, json.NewMapValue())
+		if records_errors != nil {
+			return nil, records_errors
 		}
 
-		use_database_errors := client.UseDatabase(*database)
-		if use_database_errors != nil {
-			fmt.Println("use database errors ...")
-			return use_database_errors
+		if records.Len() == 0 {
+			return nil, nil
 		}
 
-		disable_global_logs_errors := database.GlobalGeneralLogDisable()
-		if disable_global_logs_errors != nil {
-			return disable_global_logs_errors
-		}
+		variables := make(map[string]string)
+		for index := 0; index < records.Len(); index++ {
+			record, record_errors := records.GetMap(index)
+			if record_errors != nil {
+				return nil, record_errors
+			}
 
-		set_utc_time_errors := database.GlobalSetTimeZoneUTC()
-		if set_utc_time_errors != nil {
-			return set_utc_time_errors
-		}
+			variable_name, variable_name_errors := record.GetStringValue("Variable_name")
+			if variable_name_errors != nil {
+				return nil, variable_name_errors
+			}
 
-		set_sql_mode_errors := database.GlobalSetSQLMode()
-		if set_sql_mode_errors != nil {
-			return set_sql_mode_errors
+			value, value_errors := record.GetStringValue("Value")
+			if value_errors != nil {
+				return nil, value_errors
+			}
+			variables[variable_name] = value
 		}
+		return newPasswordPolicyFromVariables(variables)
+	}
+
+	// generatePassword fails before any account is created or changed when the generator cannot meet the policy
+	generatePassword := func(password_policy *PasswordPolicy) (string, []error) {
+		return generatePasswordForPolicy(config.GetPasswordGenerator(), password_policy)
+	}
 
-		database_filter := db_name
-		table_filter := "*"
+	getDatabaseTables := func(sql_command *SQLCommand) ([]string, []error) {
+		var tables []string
+		select_tables_sql, select_tables_sql_errors := getSelectTablesSQL(getDatabaseName())
+		if select_tables_sql_errors != nil {
+			return nil, select_tables_sql_errors
+		}
 
-		migration_user_exists, migration_user_exists_errors := client.UserExists(migration_db_username)
-		if migration_user_exists_errors != nil {
-			return migration_user_exists_errors
+		var sql_builder strings.Builder
+		sql_builder.WriteString(select_tables_sql)
+		records, records_errors := sql_command.ExecuteUnsafeCommand(sql_builder, json.NewMapValue())
+		if records_errors != nil {
+			return nil, records_errors
 		}
 
-		if !migration_user_exists {
-			_, create_migration_user_errs := client.CreateUser(migration_db_username, migration_db_password, db_hostname)
-			if create_migration_user_errs != nil {
-				return create_migration_user_errs
-			}
-		} else {
-			migration_db_user, migration_db_user_errors := client.GetUser(migration_db_username)
-			if migration_db_user_errors != nil {
-				return migration_db_user_errors
+		for index := 0; index < records.Len(); index++ {
+			record, record_errors := records.GetMap(index)
+			if record_errors != nil {
+				return nil, record_errors
 			}
 
-			update_password_errs := migration_db_user.UpdatePassword(migration_db_password)
-			if update_password_errs != nil {
-				return update_password_errs
+			table, table_errors := record.GetStringValue("TABLE_NAME")
+			if table_errors != nil {
+				return nil, table_errors
 			}
+			tables = append(tables, table)
 		}
+		return tables, nil
+	}
 
-		migration_db_user, migration_db_user_errors := client.GetUser(migration_db_username)
-		if migration_db_user_errors != nil {
-			return migration_db_user_errors
-		}
+	database_lock := &sync.Mutex{}
 
-		_, grant_migration_db_user_errors := client.Grant(*migration_db_user, "ALL", &database_filter, &table_filter)
-		if grant_migration_db_user_errors != nil {
-			return grant_migration_db_user_errors
-		}
+	// tableExists asks the db client one table at a time, the workers provisioning users share one database
+	tableExists := func(database *dao.Database, table string) (bool, []error) {
+		database_lock.Lock()
+		defer database_lock.Unlock()
+		return database.TableExists(table)
+	}
 
-		migration_errors := writeCredentialsFile(migration_host_users, db_hostname, db_port_number, db_name, migration_db_username, migration_db_password, -1)
-		if migration_errors != nil {
-			return migration_errors
+	// getRoleTables returns * when the role is granted the whole database, otherwise the existing tables it is granted
+	getRoleTables := func(step_logger *slog.Logger, sql_command *SQLCommand, database *dao.Database, role *RoleDefinition) ([]string, []error) {
+		if role.IsAllTables() && len(role.GetExcludedTables()) == 0 {
+			return []string{"*"}, nil
 		}
 
-		user_count := 0
-		for user_count < 100 {
-			fmt.Print(".")
-			write_user_exists, write_user_exists_errors := client.UserExists(write_db_username + fmt.Sprintf("%d", user_count))
-			if write_user_exists_errors != nil {
-				return write_user_exists_errors
+		var tables []string
+		if role.IsAllTables() {
+			database_tables, database_tables_errors := getDatabaseTables(sql_command)
+			if database_tables_errors != nil {
+				return nil, database_tables_errors
 			}
 
-			if !write_user_exists {
-				_, create_write_user_errs := client.CreateUser(write_db_username+fmt.Sprintf("%d", user_count), write_db_password, db_hostname)
-				if create_write_user_errs != nil {
-					return create_write_user_errs
+			for _, table := range database_tables {
+				if !containsString(role.GetExcludedTables(), table) {
+					tables = append(tables, table)
 				}
-			} else {
+			}
+			return tables, nil
+		}
 
-				write_db_user, create_write_user_errs := client.GetUser(write_db_username + fmt.Sprintf("%d", user_count))
-				if create_write_user_errs != nil {
-					return create_write_user_errs
-				}
+		for _, table := range role.GetTables() {
+			if containsString(role.GetExcludedTables(), table) {
+				continue
+			}
 
-				update_password_errs := write_db_user.UpdatePassword(write_db_password)
-				if update_password_errs != nil {
-					return update_password_errs
-				}
+			table_exists, table_exists_errors := tableExists(database, table)
+			if table_exists_errors != nil {
+				return nil, table_exists_errors
 			}
 
-			write_db_user, write_db_user_errors := client.GetUser(write_db_username + fmt.Sprintf("%d", user_count))
-			if write_db_user_errors != nil {
-				return write_db_user_errors
+			if !table_exists {
+				step_logger.Warn("table does not exist yet, run install again once it is created", LOG_KEY_ROLE(), role.GetName(), "table", table)
+				continue
 			}
+			tables = append(tables, table)
+		}
+		return tables, nil
+	}
 
-			_, grant_write_db_user_errors := client.Grant(*write_db_user, "INSERT", &database_filter, &table_filter)
-			if grant_write_db_user_errors != nil {
-				return grant_write_db_user_errors
+	// getRoleGrantsSQL grants the privileges of a role to several accounts with one statement per table,
+	// every grant is also returned as a description for the install report
+	getRoleGrantsSQL := func(step_logger *slog.Logger, sql_command *SQLCommand, database *dao.Database, role *RoleDefinition, accounts []databaseAccount) (string, []string, []error) {
+		var grants_sql strings.Builder
+		var grants []string
+		if len(role.GetPrivileges()) > 0 {
+			if role.IsAllTables() && len(role.GetExcludedTables()) > 0 {
+				// a database level grant from an earlier install would still cover the excluded tables
+				revoke_sql, revoke_sql_errors := getRevokeDatabaseLevelSQL(role.GetPrivileges(), getDatabaseName(), accounts)
+				if revoke_sql_errors != nil {
+					return "", nil, revoke_sql_errors
+				}
+				grants_sql.WriteString(revoke_sql)
 			}
 
-			_, grant_write_db_user_errors2 := client.Grant(*write_db_user, "UPDATE", &database_filter, &table_filter)
-			if grant_write_db_user_errors2 != nil {
-				return grant_write_db_user_errors2
+			tables, tables_errors := getRoleTables(step_logger, sql_command, database, role)
+			if tables_errors != nil {
+				return "", nil, tables_errors
 			}
 
-			_, grant_write_db_user_errors3 := client.Grant(*write_db_user, "SELECT", &database_filter, &table_filter)
-			if grant_write_db_user_errors3 != nil {
-				return grant_write_db_user_errors3
+			for _, table := range tables {
+				grant_sql, grant_sql_errors := getGrantSQL(role.GetPrivileges(), getDatabaseName(), table, accounts)
+				if grant_sql_errors != nil {
+					return "", nil, grant_sql_errors
+				}
+				grants_sql.WriteString(grant_sql)
+				grants = append(grants, strings.Join(role.GetPrivileges(), ", ")+" ON "+getDatabaseName()+"."+table)
 			}
+		}
 
-			write_errors := writeCredentialsFile(write_host_users, db_hostname, db_port_number, db_name, write_db_username, write_db_password, user_count)
-			if write_errors != nil {
-				return write_errors
+		for _, column_privilege := range role.GetColumnPrivileges() {
+			table_exists, table_exists_errors := tableExists(database, column_privilege.GetTable())
+			if table_exists_errors != nil {
+				return "", nil, table_exists_errors
 			}
 
-			read_user_exists, read_user_exists_errors := client.UserExists(read_db_username + fmt.Sprintf("%d", user_count))
-			if read_user_exists_errors != nil {
-				return read_user_exists_errors
+			if !table_exists {
+				step_logger.Warn("table does not exist yet, run install again once it is created", LOG_KEY_ROLE(), role.GetName(), "table", column_privilege.GetTable())
+				continue
 			}
 
-			if !read_user_exists {
-				read_db_user, create_read_user_errs := client.CreateUser(read_db_username+fmt.Sprintf("%d", user_count), read_db_password, db_hostname)
-				if create_read_user_errs != nil {
-					return create_read_user_errs
-				} else {
-					update_password_errs := read_db_user.UpdatePassword(read_db_password)
-					if update_password_errs != nil {
-						return update_password_errs
-					}
-				}
-			} else {
-				read_db_user, read_db_user_errors := client.GetUser(read_db_username + fmt.Sprintf("%d", user_count))
-				if read_db_user_errors != nil {
-					return read_db_user_errors
-				}
+			column_grant_sql, column_grant_sql_errors := getColumnGrantSQL(column_privilege.GetPrivileges(), column_privilege.GetColumns(), getDatabaseName(), column_privilege.GetTable(), accounts)
+			if column_grant_sql_errors != nil {
+				return "", nil, column_grant_sql_errors
+			}
+			grants_sql.WriteString(column_grant_sql)
+			grants = append(grants, column_privilege.GetGrant(getDatabaseName()))
+		}
+		return grants_sql.String(), grants, nil
+	}
 
-				update_password_errs := read_db_user.UpdatePassword(read_db_password)
-				if update_password_errs != nil {
-					return update_password_errs
-				}
+	getDesiredGrants := func(step_logger *slog.Logger, sql_command *SQLCommand, database *dao.Database, role *RoleDefinition) ([]privilegeGrant, []error) {
+		var desired_grants []privilegeGrant
+		if len(role.GetPrivileges()) > 0 {
+			tables, tables_errors := getRoleTables(step_logger, sql_command, database, role)
+			if tables_errors != nil {
+				return nil, tables_errors
 			}
 
-			read_db_user, read_db_user_errors := client.GetUser(read_db_username + fmt.Sprintf("%d", user_count))
-			if read_db_user_errors != nil {
-				return read_db_user_errors
+			for _, table := range tables {
+				for _, privilege := range role.GetPrivileges() {
+					desired_grants = append(desired_grants, newPrivilegeGrant(getDatabaseName(), table, privilege, ""))
+				}
 			}
+		}
 
-			_, grant_read_db_user_errors := client.Grant(*read_db_user, "SELECT", &database_filter, &table_filter)
-			if grant_read_db_user_errors != nil {
-				return grant_read_db_user_errors
+		for _, column_privilege := range role.GetColumnPrivileges() {
+			table_exists, table_exists_errors := tableExists(database, column_privilege.GetTable())
+			if table_exists_errors != nil {
+				return nil, table_exists_errors
 			}
 
-			read_errors := writeCredentialsFile(read_host_users, db_hostname, db_port_number, db_name, read_db_username, read_db_password, user_count)
-			if read_errors != nil {
-				return read_errors
+			if !table_exists {
+				step_logger.Warn("table does not exist yet, run install again once it is created", LOG_KEY_ROLE(), role.GetName(), "table", column_privilege.GetTable())
+				continue
 			}
 
-			user_count++
+			for _, privilege := range column_privilege.GetPrivileges() {
+				for _, column := range column_privilege.GetColumns() {
+					desired_grants = append(desired_grants, newPrivilegeGrant(getDatabaseName(), column_privilege.GetTable(), privilege, column))
+				}
+			}
 		}
+		return desired_grants, nil
+	}
 
-		set_database_username_errors := client.SetDatabaseUsername(migration_db_username)
-		if set_database_username_errors != nil {
-			return set_database_username_errors
+	// getCurrentGrants reads the privileges and the granted mysql roles of an account
+	getCurrentGrants := func(sql_command *SQLCommand, username string, host_name string) ([]privilegeGrant, []databaseAccount, []error) {
+		show_grants_sql, show_grants_sql_errors := getShowGrantsSQL(username, host_name)
+		if show_grants_sql_errors != nil {
+			return nil, nil, show_grants_sql_errors
 		}
 
-		data_migration_table_exists, data_migration_table_exists_errors := database.TableExists("DatabaseMigration")
-		if data_migration_table_exists_errors != nil {
-			return data_migration_table_exists_errors
+		var sql_builder strings.Builder
+		sql_builder.WriteString(show_grants_sql)
+		records, records_errors := sql_command.ExecuteUnsafeCommand(sql_builder, json.NewMapValue())
+		if records_errors != nil {
+			return nil, nil, records_errors
 		}
 
-		if !data_migration_table_exists {
+		var current_grants []privilegeGrant
+		var current_roles []databaseAccount
+		for index := 0; index < records.Len(); index++ {
+			record, record_errors := records.GetMap(index)
+			if record_errors != nil {
+				return nil, nil, record_errors
+			}
 
-			database_migration_schema := json.NewMapValue()
+			// the only column is named after the account, Grants for user@host
+			for _, column_name := range record.GetKeys() {
+				grant_line, grant_line_errors := record.GetStringValue(column_name)
+				if grant_line_errors != nil {
+					return nil, nil, grant_line_errors
+				}
 
-			primary_key_column_schema := json.NewMapValue()
-			primary_key_column_schema.SetStringValue("type", "uint64")
-			primary_key_column_schema.SetBoolValue("auto_increment", true)
-			primary_key_column_schema.SetBoolValue("primary_key", true)
+				grants, grants_errors := parsePrivilegeGrants(grant_line)
+				if grants_errors != nil {
+					return nil, nil, grants_errors
+				}
+				current_grants = append(current_grants, grants...)
 
-			current_column_schema := json.NewMapValue()
-			current_column_schema.SetStringValue("type", "int64")
-			current_column_schema.SetInt64Value("default", int64(-1))
+				roles, roles_errors := parseGrantedRoles(grant_line)
+				if roles_errors != nil {
+					return nil, nil, roles_errors
+				}
+				current_roles = append(current_roles, roles...)
+			}
+		}
+		return current_grants, current_roles, nil
+	}
 
-			desired_column_schema := json.NewMapValue()
-			desired_column_schema.SetStringValue("type", "int64")
-			desired_column_schema.SetInt64Value("default", int64(0))
+	// reconcileAccount revokes what an account holds beyond the desired privileges and mysql roles and grants what is missing,
+	// with report_only the drift is only recorded. drift_report, install_report and install_journal may be nil
+	reconcileAccount := func(drift_report *DriftReport, install_report *InstallReport, install_journal *InstallJournal, step_logger *slog.Logger, sql_command *SQLCommand, role *RoleDefinition, username string, host_name string, desired_grants []privilegeGrant, desired_roles []databaseAccount, report_only bool) []error {
+		current_grants, current_roles, current_grants_errors := getCurrentGrants(sql_command, username, host_name)
+		if current_grants_errors != nil {
+			return current_grants_errors
+		}
 
-			database_migration_schema.SetMapValue("database_migration_id", primary_key_column_schema)
-			database_migration_schema.SetMapValue("current", current_column_schema)
-			database_migration_schema.SetMapValue("desired", desired_column_schema)
+		if drift_report != nil {
+			drift_report.AddUser()
+		}
 
-			_, create_table_errors := database.CreateTable("DatabaseMigration", database_migration_schema)
-			if create_table_errors != nil {
-				return create_table_errors
+		var desired []accountGrant
+		for _, desired_grant := range desired_grants {
+			grant_sql, grant_sql_errors := getGrantPrivilegeGrantSQL(desired_grant, username, host_name)
+			if grant_sql_errors != nil {
+				return grant_sql_errors
 			}
+
+			revoke_sql, revoke_sql_errors := getRevokePrivilegeGrantSQL(desired_grant, username, host_name)
+			if revoke_sql_errors != nil {
+				return revoke_sql_errors
+			}
+			desired = append(desired, accountGrant{description: getPrivilegeGrantDescription(desired_grant), sql: grant_sql, undo_sql: revoke_sql})
 		}
 
-		data_migration_table, data_migration_table_errors := database.GetTable("DatabaseMigration")
+		for _, desired_role := range desired_roles {
+			grant_role_sql, grant_role_sql_errors := getGrantRoleSQL(desired_role.username, []databaseAccount{{username: username, host_name: host_name}})
+			if grant_role_sql_errors != nil {
+				return grant_role_sql_errors
+			}
 
-		if data_migration_table_errors != nil {
-			return data_migration_table_errors
+			revoke_role_sql, revoke_role_sql_errors := getRevokeRoleSQL(desired_role, username, host_name)
+			if revoke_role_sql_errors != nil {
+				return revoke_role_sql_errors
+			}
+			desired = append(desired, accountGrant{description: getGrantedRoleDescription(desired_role), sql: grant_role_sql, undo_sql: revoke_role_sql})
 		}
 
-		data_migration_table_record_count, data_migration_table_record_count_errors := data_migration_table.Count(nil, nil, nil, nil, nil)
-		if data_migration_table_record_count_errors != nil {
-			return data_migration_table_record_count_errors
+		var current []accountGrant
+		for _, current_grant := range current_grants {
+			revoke_sql, revoke_sql_errors := getRevokePrivilegeGrantSQL(current_grant, username, host_name)
+			if revoke_sql_errors != nil {
+				return revoke_sql_errors
+			}
+
+			grant_sql, grant_sql_errors := getGrantPrivilegeGrantSQL(current_grant, username, host_name)
+			if grant_sql_errors != nil {
+				return grant_sql_errors
+			}
+			current = append(current, accountGrant{description: getPrivilegeGrantDescription(current_grant), sql: revoke_sql, undo_sql: grant_sql})
 		}
 
-		if *data_migration_table_record_count > 0 {
-			return nil
+		for _, current_role := range current_roles {
+			revoke_role_sql, revoke_role_sql_errors := getRevokeRoleSQL(current_role, username, host_name)
+			if revoke_role_sql_errors != nil {
+				return revoke_role_sql_errors
+			}
+
+			grant_role_sql, grant_role_sql_errors := getRegrantRoleSQL(current_role, username, host_name)
+			if grant_role_sql_errors != nil {
+				return grant_role_sql_errors
+			}
+			current = append(current, accountGrant{description: getGrantedRoleDescription(current_role), sql: revoke_role_sql, undo_sql: grant_role_sql})
 		}
 
-		default_record := json.NewMapValue()
-		inserted_record, inserted_record_errors := data_migration_table.CreateRecord(default_record)
-		if inserted_record_errors != nil {
-			return inserted_record_errors
+		desired_descriptions := make(map[string]bool)
+		for _, desired_grant := range desired {
+			desired_descriptions[desired_grant.description] = true
 		}
 
-		_, inserted_record_value_errors := inserted_record.GetUInt64("database_migration_id")
-		if inserted_record_value_errors != nil {
-			return inserted_record_value_errors
+		current_descriptions := make(map[string]bool)
+		for _, current_grant := range current {
+			current_descriptions[current_grant.description] = true
 		}
 
-		return nil
-	}
+		for _, current_grant := range current {
+			if desired_descriptions[current_grant.description] {
+				continue
+			}
 
-	validate := func() []error {
-		var errors []error
-		temp_database_hostname := getDatabaseHostName()
-		temp_database_port_number := getDatabasePortNumber()
-		temp_database_name := getDatabaseName()
-		temp_database_username := getDatabaseRootUsername()
-		temp_database_password := getDatabaseRootPassword()
+			if report_only {
+				step_logger.Warn("extra privilege", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), username, "grant", current_grant.description)
+				if drift_report != nil {
+					drift_report.AddDrift(role.GetName(), username, host_name, DRIFT_EXTRA(), current_grant.description, ACTION_REPORT())
+				}
+				continue
+			}
 
-		database_host_name_errors := verify.ValidateDomainName(temp_database_hostname)
-		if database_host_name_errors != nil {
-			errors = append(errors, database_host_name_errors...)
-		}
+			revoke_errors := executeSQL(sql_command, current_grant.sql)
+			if revoke_errors != nil {
+				return revoke_errors
+			}
 
-		database_port_number_errors := verify.ValidatePortNumber(temp_database_port_number)
-		if database_port_number_errors != nil {
-			errors = append(errors, database_port_number_errors...)
-		}
+			step_logger.Info("revoked privilege", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), username, "grant", current_grant.description)
+			if install_journal != nil {
+				undo_sql := current_grant.undo_sql
+				install_journal.Record("revoke "+current_grant.description+" from "+getAccountName(username, host_name), func() []error {
+					return executeSQL(sql_command, undo_sql)
+				})
+			}
 
-		database_name_errors := verify.ValidateDatabaseName(temp_database_name)
-		if database_name_errors != nil {
-			errors = append(errors, database_name_errors...)
-		}
+			if drift_report != nil {
+				drift_report.AddDrift(role.GetName(), username, host_name, DRIFT_EXTRA(), current_grant.description, ACTION_REVOKE())
+			}
 
-		username_errors := verify.ValidateUsername(temp_database_username)
-		if username_errors != nil {
-			errors = append(errors, username_errors...)
+			if install_report != nil {
+				install_report.AddRevoke(role.GetName(), username, current_grant.description)
+			}
 		}
 
-		password_errors := verify.ValidateBase64Encoding(temp_database_password)
-		if password_errors != nil {
-			errors = append(errors, password_errors...)
-		}
+		for _, desired_grant := range desired {
+			if current_descriptions[desired_grant.description] {
+				continue
+			}
 
-		if errors != nil {
-			return errors
-		}
+			if report_only {
+				step_logger.Warn("missing privilege", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), username, "grant", desired_grant.description)
+				if drift_report != nil {
+					drift_report.AddDrift(role.GetName(), username, host_name, DRIFT_MISSING(), desired_grant.description, ACTION_REPORT())
+				}
+				continue
+			}
 
-		return nil
+			grant_errors := executeSQL(sql_command, desired_grant.sql)
+			if grant_errors != nil {
+				return grant_errors
+			}
+
+			step_logger.Info("granted missing privilege", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), username, "grant", desired_grant.description)
+			if install_journal != nil {
+				undo_sql := desired_grant.undo_sql
+				install_journal.Record("grant "+desired_grant.description+" to "+getAccountName(username, host_name), func() []error {
+					return executeSQL(sql_command, undo_sql)
+				})
+			}
+
+			if drift_report != nil {
+				drift_report.AddDrift(role.GetName(), username, host_name, DRIFT_MISSING(), desired_grant.description, ACTION_GRANT())
+			}
+
+			if install_report != nil {
+				install_report.AddGrant(role.GetName(), username, desired_grant.description)
+			}
+		}
+		return nil
+	}
+
+	getMySQLRole := func(role *RoleDefinition) databaseAccount {
+		return databaseAccount{username: role.GetMySQLRoleName(), host_name: MYSQL_ROLE_HOST()}
+	}
+
+	mySQLRoleExists := func(sql_command *SQLCommand, role *RoleDefinition) (bool, []error) {
+		hosts, hosts_errors := getUserHosts(sql_command, role.GetMySQLRoleName())
+		if hosts_errors != nil {
+			return false, hosts_errors
+		}
+		return containsString(hosts, MYSQL_ROLE_HOST()), nil
+	}
+
+	// reconcileMySQLRole compares the privileges of the mysql role of a role with the privileges of the role
+	reconcileMySQLRole := func(drift_report *DriftReport, install_report *InstallReport, install_journal *InstallJournal, step_logger *slog.Logger, sql_command *SQLCommand, database *dao.Database, role *RoleDefinition, report_only bool) []error {
+		desired_grants, desired_grants_errors := getDesiredGrants(step_logger, sql_command, database, role)
+		if desired_grants_errors != nil {
+			return desired_grants_errors
+		}
+		return reconcileAccount(drift_report, install_report, install_journal, step_logger, sql_command, role, role.GetMySQLRoleName(), MYSQL_ROLE_HOST(), desired_grants, nil, report_only)
+	}
+
+	// reconcileRoleUser leaves a user with nothing but the mysql role of its role, privileges granted
+	// to the user itself by installs from before mysql roles are revoked
+	reconcileRoleUser := func(drift_report *DriftReport, install_report *InstallReport, install_journal *InstallJournal, step_logger *slog.Logger, sql_command *SQLCommand, role *RoleDefinition, username string, host_name string, report_only bool) []error {
+		return reconcileAccount(drift_report, install_report, install_journal, step_logger, sql_command, role, username, host_name, nil, []databaseAccount{getMySQLRole(role)}, report_only)
+	}
+
+	// getExistingAccounts reads the accounts of several users with one query, every existing account comes with the
+	// statement that puts its current password hash back, keyed by its account name
+	getExistingAccounts := func(sql_command *SQLCommand, usernames []string) ([]databaseAccount, map[string]string, []error) {
+		select_accounts_sql, select_accounts_sql_errors := getSelectUsersAuthenticationSQL(usernames)
+		if select_accounts_sql_errors != nil {
+			return nil, nil, select_accounts_sql_errors
+		}
+
+		var sql_builder strings.Builder
+		sql_builder.WriteString(select_accounts_sql)
+		records, records_errors := sql_command.ExecuteUnsafeCommand(sql_builder, json.NewMapValue())
+		if records_errors != nil {
+			return nil, nil, records_errors
+		}
+
+		var existing_accounts []databaseAccount
+		restore_authentication_sql := make(map[string]string)
+		for index := 0; index < records.Len(); index++ {
+			record, record_errors := records.GetMap(index)
+			if record_errors != nil {
+				return nil, nil, record_errors
+			}
+
+			var values []string
+			for _, column := range []string{"User", "Host", "plugin", "authentication_string"} {
+				value, value_errors := record.GetStringValue(column)
+				if value_errors != nil {
+					return nil, nil, value_errors
+				}
+				values = append(values, value)
+			}
+
+			restore_sql, restore_sql_errors := getRestoreUserAuthenticationSQL(values[0], values[1], values[2], values[3])
+			if restore_sql_errors != nil {
+				return nil, nil, restore_sql_errors
+			}
+			existing_accounts = append(existing_accounts, databaseAccount{username: values[0], host_name: values[1]})
+			restore_authentication_sql[getAccountName(values[0], values[1])] = restore_sql
+		}
+		return existing_accounts, restore_authentication_sql, nil
+	}
+
+	// installMySQLRole creates the mysql role of a role and grants it the privileges of the role. an existing mysql role
+	// is reconciled instead, it loses the privileges the role no longer asks for and every grant and revoke is journaled
+	installMySQLRole := func(install_report *InstallReport, install_journal *InstallJournal, step_logger *slog.Logger, sql_command *SQLCommand, database *dao.Database, role *RoleDefinition) []error {
+		mysql_role := getMySQLRole(role)
+		mysql_role_name := getAccountName(mysql_role.username, mysql_role.host_name)
+		mysql_role_exists, mysql_role_exists_errors := mySQLRoleExists(sql_command, role)
+		if mysql_role_exists_errors != nil {
+			return mysql_role_exists_errors
+		}
+
+		if mysql_role_exists {
+			return reconcileMySQLRole(nil, install_report, install_journal, step_logger, sql_command, database, role, false)
+		}
+
+		var script strings.Builder
+		create_role_sql, create_role_sql_errors := getCreateRoleSQL(mysql_role.username)
+		if create_role_sql_errors != nil {
+			return create_role_sql_errors
+		}
+		script.WriteString(create_role_sql)
+
+		// dropping the new mysql role also takes back everything granted to it
+		drop_role_sql, drop_role_sql_errors := getDropRoleSQL(mysql_role.username)
+		if drop_role_sql_errors != nil {
+			return drop_role_sql_errors
+		}
+		install_journal.Record("create role "+mysql_role_name, func() []error {
+			return executeSQL(sql_command, drop_role_sql)
+		})
+
+		grants_sql, grants, grants_sql_errors := getRoleGrantsSQL(step_logger, sql_command, database, role, []databaseAccount{mysql_role})
+		if grants_sql_errors != nil {
+			return grants_sql_errors
+		}
+		script.WriteString(grants_sql)
+
+		script_errors := executeSQL(sql_command, script.String())
+		if script_errors != nil {
+			return script_errors
+		}
+
+		step_logger.Info("created role", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), mysql_role.username)
+		install_report.AddUser(role.GetName(), mysql_role_name, "created")
+
+		for _, grant := range grants {
+			step_logger.Debug("granted privileges", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), mysql_role.username, "grant", grant)
+			install_report.AddGrant(role.GetName(), mysql_role_name, grant)
+		}
+		return nil
+	}
+
+	// installRoleUsers creates or updates the accounts of several users of a role on every allowed host, their accounts on
+	// hosts the role no longer allows are left to dropRemovedUsers. all changes go to the server as one script, only accounts
+	// that existed before are read back with SHOW GRANTS as new accounts hold nothing but the mysql role of the role
+	installRoleUsers := func(install_report *InstallReport, install_journal *InstallJournal, step_logger *slog.Logger, sql_command *SQLCommand, role *RoleDefinition, user_counts []int, passwords map[string]string) []error {
+		var usernames []string
+		for _, user_count := range user_counts {
+			usernames = append(usernames, role.GetUsername()+getUserCountAsString(user_count))
+		}
+		step_logger.Debug("ensuring users", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), strings.Join(usernames, ", "))
+
+		_, restore_authentication_sql, existing_accounts_errors := getExistingAccounts(sql_command, usernames)
+		if existing_accounts_errors != nil {
+			return existing_accounts_errors
+		}
+
+		var accounts []databaseAccount
+		var created_accounts []databaseAccount
+		var updated_accounts []databaseAccount
+		for _, username := range usernames {
+			for _, host_name := range getRoleHosts(role) {
+				account := databaseAccount{username: username, host_name: host_name, password: passwords[username]}
+				accounts = append(accounts, account)
+				if _, found := restore_authentication_sql[getAccountName(username, host_name)]; found {
+					updated_accounts = append(updated_accounts, account)
+				} else {
+					created_accounts = append(created_accounts, account)
+				}
+			}
+		}
+
+		var script strings.Builder
+		if len(created_accounts) > 0 {
+			create_users_sql, create_users_sql_errors := getCreateUsersSQL(created_accounts, role.GetAuthenticationPlugin(), role.GetSocketUsername())
+			if create_users_sql_errors != nil {
+				return create_users_sql_errors
+			}
+			script.WriteString(create_users_sql)
+		}
+
+		if len(updated_accounts) > 0 {
+			// IDENTIFIED WITH also moves an existing account over when the plugin of the role changed
+			update_authentication_sql, update_authentication_sql_errors := getAlterUsersAuthenticationSQL(updated_accounts, role.GetAuthenticationPlugin(), role.GetSocketUsername())
+			if update_authentication_sql_errors != nil {
+				return update_authentication_sql_errors
+			}
+			script.WriteString(update_authentication_sql)
+		}
+
+		if role.GetTLSRequirement() != nil {
+			require_tls_sql, require_tls_sql_errors := getRequireTLSSQL(accounts, role.GetTLSRequirement())
+			if require_tls_sql_errors != nil {
+				return require_tls_sql_errors
+			}
+			script.WriteString(require_tls_sql)
+		}
+
+		if role.GetAccountOptions() != nil && !role.GetAccountOptions().IsEmpty() {
+			account_options_sql, account_options_sql_errors := getAlterUserAccountOptionsSQL(accounts, role.GetAccountOptions())
+			if account_options_sql_errors != nil {
+				return account_options_sql_errors
+			}
+			script.WriteString(account_options_sql)
+		}
+
+		grant_role_sql, grant_role_sql_errors := getGrantRoleSQL(role.GetMySQLRoleName(), accounts)
+		if grant_role_sql_errors != nil {
+			return grant_role_sql_errors
+		}
+		script.WriteString(grant_role_sql)
+
+		// the journal is written before the script runs, the server keeps the statements that ran before a failing one
+		for _, account := range created_accounts {
+			drop_user_sql, drop_user_sql_errors := getDropUserSQL(account.username, account.host_name)
+			if drop_user_sql_errors != nil {
+				return drop_user_sql_errors
+			}
+			install_journal.Record("create user "+getAccountName(account.username, account.host_name), func() []error {
+				return executeSQL(sql_command, drop_user_sql)
+			})
+		}
+
+		for _, account := range updated_accounts {
+			restore_sql := restore_authentication_sql[getAccountName(account.username, account.host_name)]
+			install_journal.Record("update authentication of "+getAccountName(account.username, account.host_name), func() []error {
+				return executeSQL(sql_command, restore_sql)
+			})
+		}
+
+		script_errors := executeSQL(sql_command, script.String())
+		if script_errors != nil {
+			return script_errors
+		}
+
+		for _, account := range created_accounts {
+			step_logger.Info("created user", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), account.username, LOG_KEY_HOST(), account.host_name)
+			install_report.AddUser(role.GetName(), getAccountName(account.username, account.host_name), "created")
+		}
+
+		for _, account := range updated_accounts {
+			step_logger.Info("updated user authentication", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), account.username, LOG_KEY_HOST(), account.host_name)
+			install_report.AddUser(role.GetName(), getAccountName(account.username, account.host_name), "updated")
+		}
+
+		for _, account := range accounts {
+			if role.GetTLSRequirement() != nil {
+				step_logger.Debug("set tls requirement", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), account.username, LOG_KEY_HOST(), account.host_name, "require", role.GetTLSRequirement().GetRequire())
+			}
+
+			if role.GetAccountOptions() != nil && !role.GetAccountOptions().IsEmpty() {
+				step_logger.Debug("set account options", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), account.username, LOG_KEY_HOST(), account.host_name, "options", role.GetAccountOptions().GetSQL())
+			}
+
+			step_logger.Debug("granted role", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), account.username, LOG_KEY_HOST(), account.host_name, "grant", getGrantedRoleDescription(getMySQLRole(role)))
+			install_report.AddGrant(role.GetName(), getAccountName(account.username, account.host_name), getGrantedRoleDescription(getMySQLRole(role)))
+		}
+
+		// privileges held by the users themselves and mysql roles other than the one of the role are revoked here
+		for _, account := range updated_accounts {
+			reconcile_errors := reconcileRoleUser(nil, install_report, install_journal, step_logger, sql_command, role, account.username, account.host_name, false)
+			if reconcile_errors != nil {
+				return reconcile_errors
+			}
+		}
+
+		for index, user_count := range user_counts {
+			write_errors := writeCredentials(getCredentialSinks(), install_report, install_journal, step_logger, role.GetHostUsers(), getDatabaseHostName(), getDatabasePortNumber(), getDatabaseName(), role.GetUsername(), passwords[usernames[index]], user_count, getRoleClientTLS(role), role.GetAuthenticationPlugin())
+			if write_errors != nil {
+				return write_errors
+			}
+		}
+		return nil
+	}
+
+	getRootClient := func() (*dao.Client, []error) {
+		client_manager, client_manager_errors := dao.NewClientManager()
+		if client_manager_errors != nil {
+			return nil, client_manager_errors
+		}
+
+		return client_manager.GetClient(getDatabaseHostName(), getDatabasePortNumber(), getDatabaseName(), getDatabaseRootUsername())
+	}
+
+	// dropRemovedRoleUsers drops the accounts of the users of a role on hosts the role no longer allows
+	dropRemovedRoleUsers := func(install_report *InstallReport, step_logger *slog.Logger, sql_command *SQLCommand, role *RoleDefinition) []error {
+		var usernames []string
+		for _, user_count := range role.GetUserCounts() {
+			usernames = append(usernames, role.GetUsername()+getUserCountAsString(user_count))
+		}
+
+		existing_accounts, _, existing_accounts_errors := getExistingAccounts(sql_command, usernames)
+		if existing_accounts_errors != nil {
+			return existing_accounts_errors
+		}
+
+		var dropped_accounts []databaseAccount
+		for _, existing_account := range existing_accounts {
+			if !containsString(getRoleHosts(role), existing_account.host_name) {
+				dropped_accounts = append(dropped_accounts, existing_account)
+			}
+		}
+
+		if len(dropped_accounts) == 0 {
+			return nil
+		}
+
+		drop_users_sql, drop_users_sql_errors := getDropUsersSQL(dropped_accounts)
+		if drop_users_sql_errors != nil {
+			return drop_users_sql_errors
+		}
+
+		drop_users_errors := executeSQL(sql_command, drop_users_sql)
+		if drop_users_errors != nil {
+			return drop_users_errors
+		}
+
+		for _, account := range dropped_accounts {
+			step_logger.Info("dropped user on a host the role no longer allows", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), account.username, LOG_KEY_HOST(), account.host_name)
+			install_report.AddUser(role.GetName(), getAccountName(account.username, account.host_name), "dropped")
+		}
+		return nil
+	}
+
+	// dropRemovedUsers drops accounts on hosts a role no longer allows and shrinks the pools. a dropped account cannot be
+	// rolled back so it only runs once the rest of the install has succeeded, a failure keeps the install and the
+	// checkpoint so --resume tries the drops again
+	dropRemovedUsers := func(install_report *InstallReport, install_checkpoint *InstallCheckpoint) []error {
+		step_logger := getStepLogger("drop_users")
+		install_report.StartStep("drop_users")
+		if install_checkpoint.IsCompleted("drop_users") {
+			step_logger.Info("users already dropped, skipping")
+			return nil
+		}
+
+		client, client_errors := getRootClient()
+		if client_errors != nil {
+			return client_errors
+		}
+
+		sql_command, sql_command_errors := getSQLCommand(client)
+		if sql_command_errors != nil {
+			return sql_command_errors
+		}
+
+		for _, role := range getRoles() {
+			drop_errors := dropRemovedRoleUsers(install_report, step_logger, sql_command, role)
+			if drop_errors != nil {
+				return drop_errors
+			}
+
+			if !role.IsPooled() {
+				continue
+			}
+
+			shrink_pool_errors := shrinkPool(install_report, step_logger, role.GetName(), sql_command, role.GetHostUsers(), role.GetUsername(), role.GetPoolSize())
+			if shrink_pool_errors != nil {
+				return shrink_pool_errors
+			}
+		}
+		return install_checkpoint.Complete("drop_users")
+	}
+
+	getCheckpointFile := func() (string, []error) {
+		if config.GetCheckpointFile() != "" {
+			return config.GetCheckpointFile(), nil
+		}
+		return getDefaultCheckpointFile(getDatabaseHostName(), getDatabasePortNumber(), getDatabaseName())
+	}
+
+	// getGlobalVariable reads the value of a global variable, found is false when the server has no such variable
+	getGlobalVariable := func(sql_command *SQLCommand, name string) (string, bool, []error) {
+		show_global_variable_sql, show_global_variable_sql_errors := getShowGlobalVariableSQL(name)
+		if show_global_variable_sql_errors != nil {
+			return "", false, show_global_variable_sql_errors
+		}
+
+		var sql_builder strings.Builder
+		sql_builder.WriteString(show_global_variable_sql)
+		records, records_errors := sql_command.ExecuteUnsafeCommand(sql_builder, json.NewMapValue())
+		if records_errors != nil {
+			return "", false, records_errors
+		}
+
+		// LIKE reads _ as a wildcard, only the row of the variable itself counts
+		for index := 0; index < records.Len(); index++ {
+			record, record_errors := records.GetMap(index)
+			if record_errors != nil {
+				return "", false, record_errors
+			}
+
+			variable_name, variable_name_errors := record.GetStringValue("Variable_name")
+			if variable_name_errors != nil {
+				return "", false, variable_name_errors
+			}
+
+			if variable_name != name {
+				continue
+			}
+
+			value, value_errors := record.GetStringValue("Value")
+			if value_errors != nil {
+				return "", false, value_errors
+			}
+			return value, true, nil
+		}
+		return "", false, nil
+	}
+
+	// install skips the global settings and users the checkpoint has as completed, its changes to users, grants,
+	// credential files and settings are journaled. accounts are only dropped by dropRemovedUsers once install has succeeded
+	install := func(install_report *InstallReport, install_journal *InstallJournal, install_checkpoint *InstallCheckpoint) []error {
+		var errors []error
+		db_hostname := getDatabaseHostName()
+		db_port_number := getDatabasePortNumber()
+		db_name := getDatabaseName()
+		root_db_username := getDatabaseRootUsername()
+		migration_db_username := getRole(ROLE_MIGRATION()).GetUsername()
+
+		step_logger := getStepLogger("root_credentials")
+		install_report.StartStep("root_credentials")
+		root_errors := writeRootCredentialsFiles(install_report, install_journal, step_logger)
+		if root_errors != nil {
+			return root_errors
+		}
+
+		unique_usernames_errors := validateUniqueDatabaseUsernames()
+		if unique_usernames_errors != nil {
+			return unique_usernames_errors
+		}
+
+		client_manager, client_manager_errors := dao.NewClientManager()
+		if client_manager_errors != nil {
+			errors = append(errors, client_manager_errors...)
+		}
+
+		if len(errors) > 0 {
+			return errors
+		}
+
+		client, client_errors := client_manager.GetClient(db_hostname, db_port_number, db_name, root_db_username)
+		if client_errors != nil {
+			errors = append(errors, client_errors...)
+		}
+
+		if len(errors) > 0 {
+			return errors
+		}
+
+		step_logger = getStepLogger("database")
+		install_report.StartStep("database")
+		database_exists, database_exists_errors := client.DatabaseExists(db_name)
+		if database_exists_errors != nil {
+			return database_exists_errors
+		}
+		install_report.SetDatabase(db_name, !database_exists)
+
+		if !database_exists {
+			character_set := config.GetCharacterSet()
+			collate := config.GetCollate()
+
+			step_logger.Info("creating database")
+			_, database_creation_errs := client.CreateDatabase(db_name, &character_set, &collate)
+			if database_creation_errs != nil {
+				errors = append(errors, database_creation_errs...)
+				return errors
+			}
+		} else {
+			step_logger.Info("database already exists, skipping")
+		}
+
+		database := client.GetDatabase()
+		set_root_database_username_errors := database.SetDatabaseUsername(root_db_username)
+		if set_root_database_username_errors != nil {
+			return set_root_database_username_errors
+		}
+
+		use_database_errors := client.UseDatabase(*database)
+		if use_database_errors != nil {
+			step_logger.Error("could not use database", "errors", fmt.Sprintf("%s", use_database_errors))
+			return use_database_errors
+		}
+
+		sql_command, sql_command_errors := getSQLCommand(client)
+		if sql_command_errors != nil {
+			return sql_command_errors
+		}
+
+		step_logger = getStepLogger("global_settings")
+		install_report.StartStep("global_settings")
+		if install_checkpoint.IsCompleted("global_settings") {
+			step_logger.Info("global settings already set, skipping")
+		} else {
+			for _, global_setting_name := range config.GetGlobalSettingNames() {
+				global_setting_value := config.GetGlobalSetting(global_setting_name)
+				set_global_sql, set_global_sql_errors := getSetGlobalSQL(global_setting_name, global_setting_value)
+				if set_global_sql_errors != nil {
+					return set_global_sql_errors
+				}
+
+				// the previous value is put back by a rollback, a variable the server does not know fails on SET
+				previous_value, previous_value_found, previous_value_errors := getGlobalVariable(sql_command, global_setting_name)
+				if previous_value_errors != nil {
+					return previous_value_errors
+				}
+
+				restore_global_sql := ""
+				if previous_value_found {
+					restore_sql, restore_sql_errors := getSetGlobalSQL(global_setting_name, previous_value)
+					if restore_sql_errors != nil {
+						return restore_sql_errors
+					}
+					restore_global_sql = restore_sql
+				}
+
+				var sql_builder strings.Builder
+				sql_builder.WriteString(set_global_sql)
+				options := json.NewMapValue()
+				options.SetBoolValue("read_no_records", true)
+				_, set_global_errors := sql_command.ExecuteUnsafeCommand(sql_builder, options)
+				if set_global_errors != nil {
+					return set_global_errors
+				}
+				step_logger.Info("set global setting", "name", global_setting_name, "value", global_setting_value)
+				if restore_global_sql != "" {
+					install_journal.Record("set global "+global_setting_name, func() []error {
+						return executeSQL(sql_command, restore_global_sql)
+					})
+				}
+
+				install_report.AddGlobalSetting(global_setting_name, global_setting_value)
+			}
+
+			checkpoint_errors := install_checkpoint.Complete("global_settings")
+			if checkpoint_errors != nil {
+				return checkpoint_errors
+			}
+
+			install_journal.Record("checkpoint global_settings", func() []error {
+				return install_checkpoint.Uncomplete("global_settings")
+			})
+		}
+
+		step_logger = getStepLogger("passwords")
+		install_report.StartStep("passwords")
+		password_policy, password_policy_errors := getPasswordPolicy(sql_command)
+		if password_policy_errors != nil {
+			return password_policy_errors
+		}
+
+		if password_policy == nil {
+			step_logger.Info("validate_password is not installed, generated passwords are not checked")
+		} else {
+			step_logger.Info("checking generated passwords against validate_password", "policy", password_policy.ToString())
+		}
+
+		// every member of a pool gets a password of its own, auth_socket users and users finished before a resume have none
+		passwords := make(map[string]string)
+		for _, role := range getRoles() {
+			if role.GetAuthenticationPlugin() == AUTHENTICATION_PLUGIN_AUTH_SOCKET() {
+				continue
+			}
+
+			for _, user_count := range role.GetUserCounts() {
+				if install_checkpoint.IsCompleted(getCheckpointUserKey(role.GetName(), user_count)) {
+					continue
+				}
+
+				password, password_errors := generatePassword(password_policy)
+				if password_errors != nil {
+					return password_errors
+				}
+				passwords[role.GetUsername()+getUserCountAsString(user_count)] = password
+			}
+		}
+
+		// the privileges of a role are granted once to its mysql role, the users of the role only get the mysql role
+		step_logger = getStepLogger("roles")
+		install_report.StartStep("roles")
+		for _, role := range getRoles() {
+			mysql_role_errors := installMySQLRole(install_report, install_journal, step_logger, sql_command, database, role)
+			if mysql_role_errors != nil {
+				return mysql_role_errors
+			}
+		}
+
+		// the users of a role are split evenly over the workers, one script per batch of users.
+		// the batches are independent of each other, all of them are tried before a failing role stops the install
+		for _, role := range getRoles() {
+			step_logger = getStepLogger("role_" + role.GetName())
+			install_report.StartStep("role_" + role.GetName())
+			var user_counts []int
+			for _, user_count := range role.GetUserCounts() {
+				if install_checkpoint.IsCompleted(getCheckpointUserKey(role.GetName(), user_count)) {
+					step_logger.Info("user already installed, skipping", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), role.GetUsername()+getUserCountAsString(user_count))
+					continue
+				}
+				user_counts = append(user_counts, user_count)
+			}
+
+			batch_size := max(1, min((len(user_counts)+config.GetConcurrency()-1)/config.GetConcurrency(), USER_BATCH_SIZE_MAXIMUM()))
+			worker_pool := getWorkerPool(client)
+			for start := 0; start < len(user_counts); start += batch_size {
+				batch_user_counts := user_counts[start:min(start+batch_size, len(user_counts))]
+				worker_pool.Submit(func(worker_sql_command *SQLCommand) []error {
+					role_users_errors := installRoleUsers(install_report, install_journal, step_logger, worker_sql_command, role, batch_user_counts, passwords)
+					if role_users_errors != nil {
+						return role_users_errors
+					}
+
+					// a user rolled back after a later failure has to be installed again on resume
+					for _, user_count := range batch_user_counts {
+						checkpoint_key := getCheckpointUserKey(role.GetName(), user_count)
+						checkpoint_errors := install_checkpoint.Complete(checkpoint_key)
+						if checkpoint_errors != nil {
+							return checkpoint_errors
+						}
+
+						install_journal.Record("checkpoint "+checkpoint_key, func() []error {
+							return install_checkpoint.Uncomplete(checkpoint_key)
+						})
+					}
+					return nil
+				})
+			}
+
+			role_errors := worker_pool.Wait()
+			if role_errors != nil {
+				return role_errors
+			}
+		}
+
+		step_logger = getStepLogger("database_migration")
+		install_report.StartStep("database_migration")
+		set_database_username_errors := client.SetDatabaseUsername(migration_db_username)
+		if set_database_username_errors != nil {
+			return set_database_username_errors
+		}
+
+		data_migration_table_exists, data_migration_table_exists_errors := database.TableExists("DatabaseMigration")
+		if data_migration_table_exists_errors != nil {
+			return data_migration_table_exists_errors
+		}
+
+		if !data_migration_table_exists {
+
+			database_migration_schema := json.NewMapValue()
+
+			primary_key_column_schema := json.NewMapValue()
+			primary_key_column_schema.SetStringValue("type", "uint64")
+			primary_key_column_schema.SetBoolValue("auto_increment", true)
+			primary_key_column_schema.SetBoolValue("primary_key", true)
+
+			current_column_schema := json.NewMapValue()
+			current_column_schema.SetStringValue("type", "int64")
+			current_column_schema.SetInt64Value("default", int64(-1))
+
+			desired_column_schema := json.NewMapValue()
+			desired_column_schema.SetStringValue("type", "int64")
+			desired_column_schema.SetInt64Value("default", int64(0))
+
+			database_migration_schema.SetMapValue("database_migration_id", primary_key_column_schema)
+			database_migration_schema.SetMapValue("current", current_column_schema)
+			database_migration_schema.SetMapValue("desired", desired_column_schema)
+
+			_, create_table_errors := database.CreateTable("DatabaseMigration", database_migration_schema)
+			if create_table_errors != nil {
+				return create_table_errors
+			}
+			step_logger.Info("created table", "table", "DatabaseMigration")
+			install_report.SetDatabaseMigrationTable("created")
+		} else {
+			install_report.SetDatabaseMigrationTable("existing")
+		}
+
+		data_migration_table, data_migration_table_errors := database.GetTable("DatabaseMigration")
+
+		if data_migration_table_errors != nil {
+			return data_migration_table_errors
+		}
+
+		data_migration_table_record_count, data_migration_table_record_count_errors := data_migration_table.Count(nil, nil, nil, nil, nil)
+		if data_migration_table_record_count_errors != nil {
+			return data_migration_table_record_count_errors
+		}
+
+		if *data_migration_table_record_count > 0 {
+			install_report.SetDatabaseMigrationSeed("existing")
+			return nil
+		}
+
+		default_record := json.NewMapValue()
+		inserted_record, inserted_record_errors := data_migration_table.CreateRecord(default_record)
+		if inserted_record_errors != nil {
+			return inserted_record_errors
+		}
+
+		_, inserted_record_value_errors := inserted_record.GetUInt64("database_migration_id")
+		if inserted_record_value_errors != nil {
+			return inserted_record_value_errors
+		}
+		step_logger.Info("seeded table", "table", "DatabaseMigration")
+		install_report.SetDatabaseMigrationSeed("created")
+
+		return nil
+	}
+
+	planCredentials := func(credential_sinks []CredentialSink, install_plan *InstallPlan, host_usernames []string, host_name string, port_number string, database_name string, username string, user_count int) []error {
+		for _, credential := range getCredentials(host_usernames, host_name, port_number, database_name, username, "", user_count, nil, "") {
+			for _, credential_sink := range credential_sinks {
+				location, location_errors := credential_sink.GetLocation(credential)
+				if location_errors != nil {
+					return location_errors
+				}
+
+				exists, exists_errors := credential_sink.Exists(credential)
+				if exists_errors != nil {
+					return exists_errors
+				}
+
+				detail := credential_sink.GetName() + " new"
+				if exists {
+					detail = credential_sink.GetName() + " replace existing"
+				}
+				install_plan.AddAction(ACTION_WRITE_FILE(), OBJECT_TYPE_FILE(), location, detail)
+			}
+		}
+		return nil
+	}
+
+	// planMySQLRole lists the mysql role of a role with the privileges install grants to it
+	planMySQLRole := func(install_plan *InstallPlan, sql_command *SQLCommand, role *RoleDefinition) []error {
+		mysql_role_exists, mysql_role_exists_errors := mySQLRoleExists(sql_command, role)
+		if mysql_role_exists_errors != nil {
+			return mysql_role_exists_errors
+		}
+
+		mysql_role_name := getAccountName(role.GetMySQLRoleName(), MYSQL_ROLE_HOST())
+		if !mysql_role_exists {
+			install_plan.AddAction(ACTION_CREATE(), OBJECT_TYPE_ROLE(), mysql_role_name, "")
+		} else {
+			install_plan.AddAction(ACTION_SKIP(), OBJECT_TYPE_ROLE(), mysql_role_name, "role already exists")
+		}
+
+		if len(role.GetPrivileges()) > 0 {
+			for _, table := range role.GetTables() {
+				if containsString(role.GetExcludedTables(), table) {
+					continue
+				}
+
+				detail := strings.Join(role.GetPrivileges(), ", ") + " ON " + getDatabaseName() + "." + table
+				if table == "*" && len(role.GetExcludedTables()) > 0 {
+					detail += " except " + strings.Join(role.GetExcludedTables(), ", ")
+				}
+				install_plan.AddAction(ACTION_GRANT(), OBJECT_TYPE_GRANT(), mysql_role_name, detail)
+			}
+		}
+
+		for _, column_privilege := range role.GetColumnPrivileges() {
+			install_plan.AddAction(ACTION_GRANT(), OBJECT_TYPE_GRANT(), mysql_role_name, column_privilege.GetGrant(getDatabaseName()))
+		}
+		return nil
+	}
+
+	planUser := func(install_plan *InstallPlan, sql_command *SQLCommand, role *RoleDefinition, username string) []error {
+		existing_hosts, existing_hosts_errors := getUserHosts(sql_command, username)
+		if existing_hosts_errors != nil {
+			return existing_hosts_errors
+		}
+
+		for _, host_name := range getRoleHosts(role) {
+			account_name := getAccountName(username, host_name)
+			if !containsString(existing_hosts, host_name) {
+				install_plan.AddAction(ACTION_CREATE(), OBJECT_TYPE_USER(), account_name, role.GetAuthenticationPlugin())
+			} else {
+				install_plan.AddAction(ACTION_UPDATE_PASSWORD(), OBJECT_TYPE_USER(), account_name, role.GetAuthenticationPlugin())
+			}
+
+			if role.GetTLSRequirement() != nil {
+				install_plan.AddAction(ACTION_SET(), OBJECT_TYPE_USER(), account_name, "REQUIRE "+role.GetTLSRequirement().GetRequire())
+			}
+
+			if role.GetAccountOptions() != nil && !role.GetAccountOptions().IsEmpty() {
+				install_plan.AddAction(ACTION_SET(), OBJECT_TYPE_USER(), account_name, role.GetAccountOptions().GetSQL())
+			}
+
+			install_plan.AddAction(ACTION_GRANT(), OBJECT_TYPE_GRANT(), account_name, getGrantedRoleDescription(getMySQLRole(role))+" as default role")
+		}
+
+		for _, existing_host := range existing_hosts {
+			if !containsString(getRoleHosts(role), existing_host) {
+				install_plan.AddAction(ACTION_DROP(), OBJECT_TYPE_USER(), getAccountName(username, existing_host), "host is not allowed by the role")
+			}
+		}
+		return nil
+	}
+
+	planShrinkPool := func(install_plan *InstallPlan, sql_command *SQLCommand, host_usernames []string, username_prefix string, pool_size int) []error {
+		pooled_users, pooled_users_errors := getPooledUsersOutsidePool(sql_command, username_prefix, pool_size)
+		if pooled_users_errors != nil {
+			return pooled_users_errors
+		}
+
+		for _, pooled_user := range pooled_users {
+			install_plan.AddAction(ACTION_DROP(), OBJECT_TYPE_USER(), getAccountName(pooled_user.username, pooled_user.host_name), fmt.Sprintf("outside pool size %d", pool_size))
+			for _, credential := range getCredentials(host_usernames, getDatabaseHostName(), getDatabasePortNumber(), getDatabaseName(), username_prefix, "", pooled_user.user_count, nil, "") {
+				for _, credential_sink := range getCredentialSinks() {
+					exists, exists_errors := credential_sink.Exists(credential)
+					if exists_errors != nil {
+						return exists_errors
+					}
+
+					if !exists {
+						continue
+					}
+
+					location, location_errors := credential_sink.GetLocation(credential)
+					if location_errors != nil {
+						return location_errors
+					}
+					install_plan.AddAction(ACTION_REMOVE_FILE(), OBJECT_TYPE_FILE(), location, credential_sink.GetName())
+				}
+			}
+		}
+		return nil
+	}
+
+	plan := func() (*InstallPlan, []error) {
+		install_plan := newInstallPlan()
+		db_hostname := getDatabaseHostName()
+		db_port_number := getDatabasePortNumber()
+		db_name := getDatabaseName()
+		root_db_username := getDatabaseRootUsername()
+
+		for _, root_database_name := range [...]string{"", db_name, "mysql"} {
+			root_errors := planCredentials(getRootCredentialSinks(), install_plan, getAllHostUsers(), db_hostname, db_port_number, root_database_name, root_db_username, -1)
+			if root_errors != nil {
+				return nil, root_errors
+			}
+		}
+
+		unique_usernames_errors := validateUniqueDatabaseUsernames()
+		if unique_usernames_errors != nil {
+			return nil, unique_usernames_errors
+		}
+
+		client_manager, client_manager_errors := dao.NewClientManager()
+		if client_manager_errors != nil {
+			return nil, client_manager_errors
+		}
+
+		client, client_errors := client_manager.GetClient(db_hostname, db_port_number, db_name, root_db_username)
+		if client_errors != nil {
+			return nil, client_errors
+		}
+
+		database_exists, database_exists_errors := client.DatabaseExists(db_name)
+		if database_exists_errors != nil {
+			return nil, database_exists_errors
+		}
+
+		if !database_exists {
+			install_plan.AddAction(ACTION_CREATE(), OBJECT_TYPE_DATABASE(), db_name, config.GetCharacterSet()+" "+config.GetCollate())
+		} else {
+			install_plan.AddAction(ACTION_SKIP(), OBJECT_TYPE_DATABASE(), db_name, "database already exists")
+		}
+
+		for _, global_setting_name := range config.GetGlobalSettingNames() {
+			install_plan.AddAction(ACTION_SET(), OBJECT_TYPE_GLOBAL_SETTING(), global_setting_name, config.GetGlobalSetting(global_setting_name))
+		}
+
+		sql_command, sql_command_errors := getSQLCommand(client)
+		if sql_command_errors != nil {
+			return nil, sql_command_errors
+		}
+
+		for _, role := range getRoles() {
+			mysql_role_errors := planMySQLRole(install_plan, sql_command, role)
+			if mysql_role_errors != nil {
+				return nil, mysql_role_errors
+			}
+		}
+
+		for _, role := range getRoles() {
+			for _, user_count := range role.GetUserCounts() {
+				role_errors := planUser(install_plan, sql_command, role, role.GetUsername()+getUserCountAsString(user_count))
+				if role_errors != nil {
+					return nil, role_errors
+				}
+
+				role_files_errors := planCredentials(getCredentialSinks(), install_plan, role.GetHostUsers(), db_hostname, db_port_number, db_name, role.GetUsername(), user_count)
+				if role_files_errors != nil {
+					return nil, role_files_errors
+				}
+			}
+		}
+
+		for _, role := range getRoles() {
+			if !role.IsPooled() {
+				continue
+			}
+
+			shrink_pool_errors := planShrinkPool(install_plan, sql_command, role.GetHostUsers(), role.GetUsername(), role.GetPoolSize())
+			if shrink_pool_errors != nil {
+				return nil, shrink_pool_errors
+			}
+		}
+
+		data_migration_table_exists := false
+		if database_exists {
+			database := client.GetDatabase()
+			table_exists, table_exists_errors := database.TableExists("DatabaseMigration")
+			if table_exists_errors != nil {
+				return nil, table_exists_errors
+			}
+			data_migration_table_exists = table_exists
+		}
+
+		if !data_migration_table_exists {
+			install_plan.AddAction(ACTION_CREATE(), OBJECT_TYPE_TABLE(), "DatabaseMigration", "")
+			install_plan.AddAction(ACTION_CREATE(), OBJECT_TYPE_RECORD(), "DatabaseMigration", "default record")
+			return install_plan, nil
+		}
+
+		install_plan.AddAction(ACTION_SKIP(), OBJECT_TYPE_TABLE(), "DatabaseMigration", "table already exists")
+
+		database := client.GetDatabase()
+		data_migration_table, data_migration_table_errors := database.GetTable("DatabaseMigration")
+		if data_migration_table_errors != nil {
+			return nil, data_migration_table_errors
+		}
+
+		data_migration_table_record_count, data_migration_table_record_count_errors := data_migration_table.Count(nil, nil, nil, nil, nil)
+		if data_migration_table_record_count_errors != nil {
+			return nil, data_migration_table_record_count_errors
+		}
+
+		if *data_migration_table_record_count > 0 {
+			install_plan.AddAction(ACTION_SKIP(), OBJECT_TYPE_RECORD(), "DatabaseMigration", "record already exists")
+		} else {
+			install_plan.AddAction(ACTION_CREATE(), OBJECT_TYPE_RECORD(), "DatabaseMigration", "default record")
+		}
+
+		return install_plan, nil
+	}
+
+	getManagedUsers := func(sql_command *SQLCommand, role *RoleDefinition) ([]pooledDatabaseUser, []error) {
+		if role.IsPooled() {
+			return getPooledUsersOutsidePool(sql_command, role.GetUsername(), 0)
+		}
+
+		var managed_users []pooledDatabaseUser
+		select_users_sql, select_users_sql_errors := getSelectUsersByPrefixSQL(role.GetUsername())
+		if select_users_sql_errors != nil {
+			return nil, select_users_sql_errors
+		}
+
+		var sql_builder strings.Builder
+		sql_builder.WriteString(select_users_sql)
+		records, records_errors := sql_command.ExecuteUnsafeCommand(sql_builder, json.NewMapValue())
+		if records_errors != nil {
+			return nil, records_errors
+		}
+
+		for index := 0; index < records.Len(); index++ {
+			record, record_errors := records.GetMap(index)
+			if record_errors != nil {
+				return nil, record_errors
+			}
+
+			username, username_errors := record.GetStringValue("User")
+			if username_errors != nil {
+				return nil, username_errors
+			}
+
+			host_name, host_name_errors := record.GetStringValue("Host")
+			if host_name_errors != nil {
+				return nil, host_name_errors
+			}
+
+			if username == role.GetUsername() {
+				managed_users = append(managed_users, pooledDatabaseUser{username: username, host_name: host_name, user_count: -1})
+			}
+		}
+
+		return managed_users, nil
+	}
+
+	uninstall := func(confirm_database_name string, keep_data bool) []error {
+		var errors []error
+		step_logger := getStepLogger("uninstall")
+		db_hostname := getDatabaseHostName()
+		db_port_number := getDatabasePortNumber()
+		db_name := getDatabaseName()
+		root_db_username := getDatabaseRootUsername()
+
+		if confirm_database_name != db_name {
+			errors = append(errors, fmt.Errorf("uninstall was not confirmed: confirmation %s does not match database name %s", confirm_database_name, db_name))
+			return errors
+		}
+
+		client_manager, client_manager_errors := dao.NewClientManager()
+		if client_manager_errors != nil {
+			return client_manager_errors
+		}
+
+		client, client_errors := client_manager.GetClient(db_hostname, db_port_number, db_name, root_db_username)
+		if client_errors != nil {
+			return client_errors
+		}
+
+		sql_command, sql_command_errors := getSQLCommand(client)
+		if sql_command_errors != nil {
+			return sql_command_errors
+		}
+
+		if !keep_data {
+			database_exists, database_exists_errors := client.DatabaseExists(db_name)
+			if database_exists_errors != nil {
+				return database_exists_errors
+			}
+
+			if database_exists {
+				database := client.GetDatabase()
+				delete_table_errors := database.DeleteTableByTableNameIfExists("DatabaseMigration")
+				if delete_table_errors != nil {
+					return delete_table_errors
+				}
+
+				step_logger.Info("dropping database")
+				delete_database_errors := client.DeleteDatabase(db_name)
+				if delete_database_errors != nil {
+					return delete_database_errors
+				}
+			}
+		}
+
+		for _, role := range getRoles() {
+			managed_users, managed_users_errors := getManagedUsers(sql_command, role)
+			if managed_users_errors != nil {
+				return managed_users_errors
+			}
+
+			for _, managed_user := range managed_users {
+				revoke_sql, revoke_sql_errors := getRevokeAllSQL(managed_user.username, managed_user.host_name)
+				if revoke_sql_errors != nil {
+					return revoke_sql_errors
+				}
+
+				drop_user_sql, drop_user_sql_errors := getDropUserSQL(managed_user.username, managed_user.host_name)
+				if drop_user_sql_errors != nil {
+					return drop_user_sql_errors
+				}
+
+				var sql_builder strings.Builder
+				sql_builder.WriteString(revoke_sql)
+				sql_builder.WriteString(drop_user_sql)
+				options := json.NewMapValue()
+				options.SetBoolValue("read_no_records", true)
+				_, drop_user_errors := sql_command.ExecuteUnsafeCommand(sql_builder, options)
+				if drop_user_errors != nil {
+					return drop_user_errors
+				}
+				step_logger.Info("dropped user", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), managed_user.username)
+			}
+
+			drop_role_sql, drop_role_sql_errors := getDropRoleSQL(role.GetMySQLRoleName())
+			if drop_role_sql_errors != nil {
+				return drop_role_sql_errors
+			}
+
+			drop_role_errors := executeSQL(sql_command, drop_role_sql)
+			if drop_role_errors != nil {
+				return drop_role_errors
+			}
+			step_logger.Info("dropped role", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), role.GetMySQLRoleName())
+
+			user_counts := make(map[int]bool)
+			for _, user_count := range role.GetUserCounts() {
+				user_counts[user_count] = true
+			}
+
+			for _, managed_user := range managed_users {
+				user_counts[managed_user.user_count] = true
+			}
+
+			for user_count := range user_counts {
+				remove_files_errors := removeCredentials(getCredentialSinks(), nil, step_logger, role.GetHostUsers(), db_hostname, db_port_number, db_name, role.GetUsername(), user_count)
+				if remove_files_errors != nil {
+					return remove_files_errors
+				}
+			}
+		}
+
+		for _, root_database_name := range [...]string{db_name, "mysql", ""} {
+			remove_root_files_errors := removeCredentials(getRootCredentialSinks(), nil, step_logger, getAllHostUsers(), db_hostname, db_port_number, root_database_name, root_db_username, -1)
+			if remove_root_files_errors != nil {
+				return remove_root_files_errors
+			}
+		}
+
+		return nil
+	}
+
+	// getRotationTargets accepts a role name such as write or a single pool member such as write:3
+	getRotationTargets := func(roles []string) ([]rotationTarget, []error) {
+		var errors []error
+		var rotation_targets []rotationTarget
+		if len(roles) == 0 {
+			var role_names []string
+			for _, role := range getRoles() {
+				role_names = append(role_names, role.GetName())
+			}
+			errors = append(errors, fmt.Errorf("no roles to rotate: use %s or a pool member such as %s:0", strings.Join(role_names, ", "), ROLE_WRITE()))
+			return nil, errors
+		}
+
+		for _, raw_role := range roles {
+			role_name, raw_user_count, is_pool_member := strings.Cut(raw_role, ":")
+			// the root password belongs to the operator, the installer never changes it on the server
+			if role_name == ROLE_ROOT() {
+				errors = append(errors, fmt.Errorf("role: %s is not rotated by the installer, change the root password on the server and run write-credentials with the new one", role_name))
+				continue
+			}
+
+			role := getRole(role_name)
+			if role == nil {
+				errors = append(errors, fmt.Errorf("unknown role: %s", raw_role))
+				continue
+			}
+
+			if !role.IsPooled() {
+				if is_pool_member {
+					errors = append(errors, fmt.Errorf("role: %s has no pool members", role_name))
+					continue
+				}
+				rotation_targets = append(rotation_targets, rotationTarget{role: raw_role, username: role.GetUsername(), user_count: -1, host_usernames: role.GetHostUsers(), host_names: getRoleHosts(role), client_tls: getRoleClientTLS(role), authentication_plugin: role.GetAuthenticationPlugin()})
+				continue
+			}
+
+			if !is_pool_member {
+				for _, user_count := range role.GetUserCounts() {
+					rotation_targets = append(rotation_targets, rotationTarget{role: raw_role, username: role.GetUsername(), user_count: user_count, host_usernames: role.GetHostUsers(), host_names: getRoleHosts(role), client_tls: getRoleClientTLS(role), authentication_plugin: role.GetAuthenticationPlugin()})
+				}
+				continue
+			}
+
+			user_count, user_count_error := strconv.Atoi(raw_user_count)
+			if user_count_error != nil || user_count < 0 || user_count >= role.GetPoolSize() {
+				errors = append(errors, fmt.Errorf("pool member: %s is not between %s:0 and %s:%d", raw_role, role_name, role_name, role.GetPoolSize()-1))
+				continue
+			}
+			rotation_targets = append(rotation_targets, rotationTarget{role: raw_role, username: role.GetUsername(), user_count: user_count, host_usernames: role.GetHostUsers(), host_names: getRoleHosts(role), client_tls: getRoleClientTLS(role), authentication_plugin: role.GetAuthenticationPlugin()})
+		}
+
+		if len(errors) > 0 {
+			return nil, errors
+		}
+
+		return rotation_targets, nil
+	}
+
+	validateRotationTargetExists := func(client *dao.Client, rotation_target rotationTarget) []error {
+		var errors []error
+		full_username := rotation_target.username + getUserCountAsString(rotation_target.user_count)
+		user_exists, user_exists_errors := client.UserExists(full_username)
+		if user_exists_errors != nil {
+			return user_exists_errors
+		}
+
+		if !user_exists {
+			errors = append(errors, fmt.Errorf("database user: %s does not exist, run install before rotating credentials", full_username))
+			return errors
+		}
+		return nil
+	}
+
+	// reconcile compares SHOW GRANTS of every managed user with its role, report_only leaves the grants as they are for audits
+	reconcile := func(report_only bool) (*DriftReport, []error) {
+		drift_report := newDriftReport(report_only)
+		step_logger := getStepLogger("reconcile")
+
+		unique_usernames_errors := validateUniqueDatabaseUsernames()
+		if unique_usernames_errors != nil {
+			return nil, unique_usernames_errors
+		}
+
+		client, client_errors := getRootClient()
+		if client_errors != nil {
+			return nil, client_errors
+		}
+
+		database_exists, database_exists_errors := client.DatabaseExists(getDatabaseName())
+		if database_exists_errors != nil {
+			return nil, database_exists_errors
+		}
+
+		if !database_exists {
+			var errors []error
+			errors = append(errors, fmt.Errorf("database: %s does not exist, run install before reconciling grants", getDatabaseName()))
+			return nil, errors
+		}
+
+		database := client.GetDatabase()
+		sql_command, sql_command_errors := getSQLCommand(client)
+		if sql_command_errors != nil {
+			return nil, sql_command_errors
+		}
+
+		for _, role := range getRoles() {
+			mysql_role_exists, mysql_role_exists_errors := mySQLRoleExists(sql_command, role)
+			if mysql_role_exists_errors != nil {
+				return nil, mysql_role_exists_errors
+			}
+
+			if !mysql_role_exists {
+				var errors []error
+				errors = append(errors, fmt.Errorf("role: %s mysql role %s does not exist, run install before reconciling grants", role.GetName(), role.GetMySQLRoleName()))
+				return drift_report, errors
+			}
+
+			mysql_role_errors := reconcileMySQLRole(drift_report, nil, nil, step_logger, sql_command, database, role, report_only)
+			if mysql_role_errors != nil {
+				return drift_report, mysql_role_errors
+			}
+
+			managed_users, managed_users_errors := getManagedUsers(sql_command, role)
+			if managed_users_errors != nil {
+				return nil, managed_users_errors
+			}
+
+			for _, managed_user := range managed_users {
+				reconcile_errors := reconcileRoleUser(drift_report, nil, nil, step_logger, sql_command, role, managed_user.username, managed_user.host_name, report_only)
+				if reconcile_errors != nil {
+					return drift_report, reconcile_errors
+				}
+			}
+		}
+
+		return drift_report, nil
+	}
+
+	// getStatusRecord returns the first record of a query or nil when there is none
+	getStatusRecord := func(sql_command *SQLCommand, raw_sql string) (*json.Map, []error) {
+		var sql_builder strings.Builder
+		sql_builder.WriteString(raw_sql)
+		records, records_errors := sql_command.ExecuteUnsafeCommand(sql_builder, json.NewMapValue())
+		if records_errors != nil {
+			return nil, records_errors
+		}
+
+		if records.Len() == 0 {
+			return nil, nil
+		}
+		return records.GetMap(0)
+	}
+
+	getGrantDescriptions := func(sql_command *SQLCommand, username string, host_name string) ([]string, []error) {
+		current_grants, current_roles, current_grants_errors := getCurrentGrants(sql_command, username, host_name)
+		if current_grants_errors != nil {
+			return nil, current_grants_errors
+		}
+
+		var grant_descriptions []string
+		for _, current_grant := range current_grants {
+			grant_descriptions = append(grant_descriptions, getPrivilegeGrantDescription(current_grant))
+		}
+
+		for _, current_role := range current_roles {
+			grant_descriptions = append(grant_descriptions, getGrantedRoleDescription(current_role))
+		}
+		return grant_descriptions, nil
+	}
+
+	// statusCredentials checks every file the sinks would hold, option files are also used to log in
+	// from the host running the installer so accounts limited to other client hosts report a failed login
+	statusCredentials := func(credential_sinks []CredentialSink, install_status *InstallStatus, step_logger *slog.Logger, client *dao.Client, credentials []DatabaseCredential) []error {
+		for _, credential := range credentials {
+			for _, credential_sink := range credential_sinks {
+				location, location_errors := credential_sink.GetLocation(credential)
+				if location_errors != nil {
+					return location_errors
+				}
+
+				exists, exists_errors := credential_sink.Exists(credential)
+				if exists_errors != nil {
+					return exists_errors
+				}
+
+				var problems []string
+				if exists {
+					verify_problems, verify_errors := credential_sink.Verify(credential)
+					if verify_errors != nil {
+						return verify_errors
+					}
+					problems = verify_problems
+				}
+
+				login := LOGIN_SKIPPED()
+				if exists && credential_sink.GetName() == "option-file" && credential.GetAuthenticationPlugin() != AUTHENTICATION_PLUGIN_AUTH_SOCKET() {
+					// the option file logs in with its own ssl-* lines, the ones its users connect with
+					login_command := newSQLCommandUsingOptionFile(client.GetHostClientUser(), getDatabaseHostName(), getDatabasePortNumber(), nil, func() (string, []error) {
+						return location, nil
+					})
+
+					var sql_builder strings.Builder
+					sql_builder.WriteString("SELECT CURRENT_USER();\n")
+					_, login_errors := login_command.ExecuteUnsafeCommand(sql_builder, json.NewMapValue())
+					login = LOGIN_OK()
+					if login_errors != nil {
+						step_logger.Warn("could not log in", LOG_KEY_USER(), credential.GetUsername(), LOG_KEY_HOST_USER(), credential.GetHostUsername(), LOG_KEY_PATH(), location, "errors", fmt.Sprintf("%s", login_errors))
+						login = LOGIN_FAILED()
+					}
+				}
+				install_status.AddCredentialFile(credential_sink.GetName(), location, credential.GetHostUsername(), credential.GetUsername(), exists, login, problems)
+			}
+		}
+		return nil
+	}
+
+	// status reads what install would change without changing anything
+	status := func() (*InstallStatus, []error) {
+		install_status := newInstallStatus(config.GetCharacterSet(), config.GetCollate())
+		step_logger := getStepLogger("status")
+
+		unique_usernames_errors := validateUniqueDatabaseUsernames()
+		if unique_usernames_errors != nil {
+			return nil, unique_usernames_errors
+		}
+
+		client, client_errors := getRootClient()
+		if client_errors != nil {
+			return nil, client_errors
+		}
+
+		sql_command, sql_command_errors := getSQLCommand(client)
+		if sql_command_errors != nil {
+			return nil, sql_command_errors
+		}
+
+		select_database_sql, select_database_sql_errors := getSelectDatabaseSQL(getDatabaseName())
+		if select_database_sql_errors != nil {
+			return nil, select_database_sql_errors
+		}
+
+		database_record, database_record_errors := getStatusRecord(sql_command, select_database_sql)
+		if database_record_errors != nil {
+			return nil, database_record_errors
+		}
+
+		var database *dao.Database
+		if database_record == nil {
+			install_status.SetDatabase(getDatabaseName(), false, "", "")
+		} else {
+			character_set, character_set_errors := database_record.GetStringValue("DEFAULT_CHARACTER_SET_NAME")
+			if character_set_errors != nil {
+				return nil, character_set_errors
+			}
+
+			collate, collate_errors := database_record.GetStringValue("DEFAULT_COLLATION_NAME")
+			if collate_errors != nil {
+				return nil, collate_errors
+			}
+			install_status.SetDatabase(getDatabaseName(), true, character_set, collate)
+			database = client.GetDatabase()
+		}
+
+		for _, role := range getRoles() {
+			mysql_role_exists, mysql_role_exists_errors := mySQLRoleExists(sql_command, role)
+			if mysql_role_exists_errors != nil {
+				return nil, mysql_role_exists_errors
+			}
+
+			if !mysql_role_exists {
+				install_status.AddAccount(role.GetName(), role.GetMySQLRoleName(), MYSQL_ROLE_HOST(), STATUS_MISSING(), nil)
+			} else {
+				grant_descriptions, grant_descriptions_errors := getGrantDescriptions(sql_command, role.GetMySQLRoleName(), MYSQL_ROLE_HOST())
+				if grant_descriptions_errors != nil {
+					return nil, grant_descriptions_errors
+				}
+				install_status.AddAccount(role.GetName(), role.GetMySQLRoleName(), MYSQL_ROLE_HOST(), STATUS_OK(), grant_descriptions)
+
+				if database != nil {
+					drift_errors := reconcileMySQLRole(install_status.GetDriftReport(), nil, nil, step_logger, sql_command, database, role, true)
+					if drift_errors != nil {
+						return nil, drift_errors
+					}
+				}
+			}
+
+			managed_users, managed_users_errors := getManagedUsers(sql_command, role)
+			if managed_users_errors != nil {
+				return nil, managed_users_errors
+			}
+
+			existing_accounts := make(map[string]bool)
+			for _, managed_user := range managed_users {
+				existing_accounts[getAccountName(managed_user.username, managed_user.host_name)] = true
+			}
+
+			expected_accounts := make(map[string]bool)
+			for _, user_count := range role.GetUserCounts() {
+				username := role.GetUsername() + getUserCountAsString(user_count)
+				for _, host_name := range getRoleHosts(role) {
+					expected_accounts[getAccountName(username, host_name)] = true
+					if !existing_accounts[getAccountName(username, host_name)] {
+						install_status.AddAccount(role.GetName(), username, host_name, STATUS_MISSING(), nil)
+						continue
+					}
+
+					grant_descriptions, grant_descriptions_errors := getGrantDescriptions(sql_command, username, host_name)
+					if grant_descriptions_errors != nil {
+						return nil, grant_descriptions_errors
+					}
+					install_status.AddAccount(role.GetName(), username, host_name, STATUS_OK(), grant_descriptions)
+
+					drift_errors := reconcileRoleUser(install_status.GetDriftReport(), nil, nil, step_logger, sql_command, role, username, host_name, true)
+					if drift_errors != nil {
+						return nil, drift_errors
+					}
+				}
+
+				credentials_errors := statusCredentials(getCredentialSinks(), install_status, step_logger, client, getCredentials(role.GetHostUsers(), getDatabaseHostName(), getDatabasePortNumber(), getDatabaseName(), role.GetUsername(), "", user_count, getRoleClientTLS(role), role.GetAuthenticationPlugin()))
+				if credentials_errors != nil {
+					return nil, credentials_errors
+				}
+			}
+
+			for _, managed_user := range managed_users {
+				if expected_accounts[getAccountName(managed_user.username, managed_user.host_name)] {
+					continue
+				}
+
+				grant_descriptions, grant_descriptions_errors := getGrantDescriptions(sql_command, managed_user.username, managed_user.host_name)
+				if grant_descriptions_errors != nil {
+					return nil, grant_descriptions_errors
+				}
+				install_status.AddAccount(role.GetName(), managed_user.username, managed_user.host_name, STATUS_UNEXPECTED(), grant_descriptions)
+			}
+		}
+
+		for _, root_database_name := range [...]string{"", getDatabaseName(), "mysql"} {
+			root_credentials_errors := statusCredentials(getRootCredentialSinks(), install_status, step_logger, client, getCredentials(getAllHostUsers(), getDatabaseHostName(), getDatabasePortNumber(), root_database_name, getDatabaseRootUsername(), "", -1, config.GetClientTLS(), ""))
+			if root_credentials_errors != nil {
+				return nil, root_credentials_errors
+			}
+		}
+
+		for _, global_setting_name := range config.GetGlobalSettingNames() {
+			value, _, value_errors := getGlobalVariable(sql_command, global_setting_name)
+			if value_errors != nil {
+				return nil, value_errors
+			}
+			install_status.AddGlobalSetting(global_setting_name, value, config.GetGlobalSetting(global_setting_name))
+		}
+
+		if database == nil {
+			return install_status, nil
+		}
+
+		database_tables, database_tables_errors := getDatabaseTables(sql_command)
+		if database_tables_errors != nil {
+			return nil, database_tables_errors
+		}
+
+		if !containsString(database_tables, "DatabaseMigration") {
+			return install_status, nil
+		}
+
+		database_migration_record, database_migration_record_errors := getStatusRecord(sql_command, getSelectDatabaseMigrationSQL(getDatabaseName()))
+		if database_migration_record_errors != nil {
+			return nil, database_migration_record_errors
+		}
+
+		if database_migration_record == nil {
+			install_status.SetDatabaseMigration(true, false, "", "")
+			return install_status, nil
+		}
+
+		current, current_errors := database_migration_record.GetStringValue("current")
+		if current_errors != nil {
+			return nil, current_errors
+		}
+
+		desired, desired_errors := database_migration_record.GetStringValue("desired")
+		if desired_errors != nil {
+			return nil, desired_errors
+		}
+		install_status.SetDatabaseMigration(true, true, current, desired)
+		return install_status, nil
+	}
+
+	// validateExportableRoles refuses roles granted single tables or columns, a new database has no tables to grant them on
+	// and GRANT fails for a table that does not exist
+	validateExportableRoles := func() []error {
+		var errors []error
+		for _, role := range getRoles() {
+			if len(role.GetPrivileges()) > 0 && (!role.IsAllTables() || len(role.GetExcludedTables()) > 0) {
+				errors = append(errors, fmt.Errorf("export: role %s is granted single tables, a new database has none, run install once the tables exist", role.GetName()))
+			}
+
+			if len(role.GetColumnPrivileges()) > 0 {
+				errors = append(errors, fmt.Errorf("export: role %s is granted columns, a new database has no tables, run install once the tables exist", role.GetName()))
+			}
+		}
+
+		if len(errors) > 0 {
+			return errors
+		}
+
+		return nil
+	}
+
+	// getNewDatabaseRoleGrantsSQL grants the privileges of a role to its mysql role on the whole new database
+	getNewDatabaseRoleGrantsSQL := func(role *RoleDefinition) (string, []error) {
+		if len(role.GetPrivileges()) == 0 {
+			return "", nil
+		}
+		return getGrantSQL(role.GetPrivileges(), getDatabaseName(), "*", []databaseAccount{getMySQLRole(role)})
+	}
+
+	// exportSQL renders what install does on a new database as one script for a database administrator to review and run,
+	// nothing connects to the server. passwords come from secrets_file, users missing from it get a generated password that
+	// is added to the file. the credential files are left to writeExportedCredentials once the script has been applied
+	exportSQL := func(secrets_file string) (string, []error) {
+		step_logger := getStepLogger("export")
+		unique_usernames_errors := validateUniqueDatabaseUsernames()
+		if unique_usernames_errors != nil {
+			return "", unique_usernames_errors
+		}
+
+		exportable_roles_errors := validateExportableRoles()
+		if exportable_roles_errors != nil {
+			return "", exportable_roles_errors
+		}
+
+		passwords, passwords_errors := loadExportSecrets(secrets_file, getDatabaseHostName(), getDatabasePortNumber(), getDatabaseName())
+		if passwords_errors != nil {
+			return "", passwords_errors
+		}
+
+		// the server is not asked for its validate_password policy, generated passwords only follow the password generator
+		for _, role := range getRoles() {
+			if role.GetAuthenticationPlugin() == AUTHENTICATION_PLUGIN_AUTH_SOCKET() {
+				continue
+			}
+
+			for _, user_count := range role.GetUserCounts() {
+				username := role.GetUsername() + getUserCountAsString(user_count)
+				if _, found := passwords[username]; found {
+					continue
+				}
+
+				password, password_errors := generatePassword(nil)
+				if password_errors != nil {
+					return "", password_errors
+				}
+				passwords[username] = password
+				step_logger.Info("generated password", LOG_KEY_ROLE(), role.GetName(), LOG_KEY_USER(), username)
+			}
+		}
+
+		var script strings.Builder
+		script.WriteString("-- install of database " + getDatabaseName() + " on " + getDatabaseHostName() + ":" + getDatabasePortNumber() + "\n")
+		script.WriteString("-- the passwords of this script are kept in " + secrets_file + ", protect it like that file\n")
+
+		script.WriteString("\n-- database\n")
+		script.WriteString(getCreateDatabaseSQL(getDatabaseName(), config.GetCharacterSet(), config.GetCollate()))
+
+		script.WriteString("\n-- global settings\n")
+		for _, global_setting_name := range config.GetGlobalSettingNames() {
+			set_global_sql, set_global_sql_errors := getSetGlobalSQL(global_setting_name, config.GetGlobalSetting(global_setting_name))
+			if set_global_sql_errors != nil {
+				return "", set_global_sql_errors
+			}
+			script.WriteString(set_global_sql)
+		}
+
+		script.WriteString("\n-- roles\n")
+		for _, role := range getRoles() {
+			create_role_sql, create_role_sql_errors := getCreateRoleSQL(role.GetMySQLRoleName())
+			if create_role_sql_errors != nil {
+				return "", create_role_sql_errors
+			}
+			script.WriteString(create_role_sql)
+
+			grants_sql, grants_sql_errors := getNewDatabaseRoleGrantsSQL(role)
+			if grants_sql_errors != nil {
+				return "", grants_sql_errors
+			}
+			script.WriteString(grants_sql)
+		}
+
+		for _, role := range getRoles() {
+			script.WriteString("\n-- users of role " + role.GetName() + "\n")
+			user_counts := role.GetUserCounts()
+			for start := 0; start < len(user_counts); start += USER_BATCH_SIZE_MAXIMUM() {
+				var accounts []databaseAccount
+				for _, user_count := range user_counts[start:min(start+USER_BATCH_SIZE_MAXIMUM(), len(user_counts))] {
+					username := role.GetUsername() + getUserCountAsString(user_count)
+					for _, host_name := range getRoleHosts(role) {
+						accounts = append(accounts, databaseAccount{username: username, host_name: host_name, password: passwords[username]})
+					}
+				}
+
+				create_users_sql, create_users_sql_errors := getCreateUsersSQL(accounts, role.GetAuthenticationPlugin(), role.GetSocketUsername())
+				if create_users_sql_errors != nil {
+					return "", create_users_sql_errors
+				}
+				script.WriteString(create_users_sql)
+
+				// CREATE USER IF NOT EXISTS leaves an existing user alone, ALTER USER gives it the password of the secrets file
+				update_authentication_sql, update_authentication_sql_errors := getAlterUsersAuthenticationSQL(accounts, role.GetAuthenticationPlugin(), role.GetSocketUsername())
+				if update_authentication_sql_errors != nil {
+					return "", update_authentication_sql_errors
+				}
+				script.WriteString(update_authentication_sql)
+
+				if role.GetTLSRequirement() != nil {
+					require_tls_sql, require_tls_sql_errors := getRequireTLSSQL(accounts, role.GetTLSRequirement())
+					if require_tls_sql_errors != nil {
+						return "", require_tls_sql_errors
+					}
+					script.WriteString(require_tls_sql)
+				}
+
+				if role.GetAccountOptions() != nil && !role.GetAccountOptions().IsEmpty() {
+					account_options_sql, account_options_sql_errors := getAlterUserAccountOptionsSQL(accounts, role.GetAccountOptions())
+					if account_options_sql_errors != nil {
+						return "", account_options_sql_errors
+					}
+					script.WriteString(account_options_sql)
+				}
+
+				grant_role_sql, grant_role_sql_errors := getGrantRoleSQL(role.GetMySQLRoleName(), accounts)
+				if grant_role_sql_errors != nil {
+					return "", grant_role_sql_errors
+				}
+				script.WriteString(grant_role_sql)
+			}
+		}
+
+		script.WriteString("\n-- DatabaseMigration\n")
+		// SET GLOBAL does not change the session running the script, the table needs the sql_mode install sets
+		if containsString(config.GetGlobalSettingNames(), "sql_mode") {
+			set_session_sql, set_session_sql_errors := getSetSessionSQL("sql_mode", config.GetGlobalSetting("sql_mode"))
+			if set_session_sql_errors != nil {
+				return "", set_session_sql_errors
+			}
+			script.WriteString(set_session_sql)
+		}
+		script.WriteString(getCreateDatabaseMigrationTableSQL(getDatabaseName()))
+		script.WriteString(getInsertDatabaseMigrationSQL(getDatabaseName()))
+
+		save_errors := saveExportSecrets(secrets_file, getDatabaseHostName(), getDatabasePortNumber(), getDatabaseName(), passwords)
+		if save_errors != nil {
+			return "", save_errors
+		}
+		step_logger.Info("saved passwords, run write-credentials with the secrets file once the script has been applied", LOG_KEY_PATH(), secrets_file)
+		return script.String(), nil
+	}
+
+	// writeExportedCredentials writes the credential files of the role users with the passwords of the secrets file of an
+	// applied export script. every user has to exist on the server and nothing is written when one does not, a failed
+	// write puts back the files written before it
+	writeExportedCredentials := func(secrets_file string) []error {
+		var errors []error
+		step_logger := getStepLogger("write_credentials")
+		passwords, passwords_errors := loadExportSecrets(secrets_file, getDatabaseHostName(), getDatabasePortNumber(), getDatabaseName())
+		if passwords_errors != nil {
+			return passwords_errors
+		}
+
+		client, client_errors := getRootClient()
+		if client_errors != nil {
+			return client_errors
+		}
+
+		for _, role := range getRoles() {
+			for _, user_count := range role.GetUserCounts() {
+				username := role.GetUsername() + getUserCountAsString(user_count)
+				if _, found := passwords[username]; !found && role.GetAuthenticationPlugin() != AUTHENTICATION_PLUGIN_AUTH_SOCKET() {
+					errors = append(errors, fmt.Errorf("secrets: %s has no password for %s, run export before writing its credential files", secrets_file, username))
+					continue
+				}
+
+				user_exists, user_exists_errors := client.UserExists(username)
+				if user_exists_errors != nil {
+					return user_exists_errors
+				}
+
+				if !user_exists {
+					errors = append(errors, fmt.Errorf("database user: %s does not exist, apply the exported script before writing its credential files", username))
+				}
+			}
+		}
+
+		if len(errors) > 0 {
+			return errors
+		}
+
+		write_journal := newInstallJournal()
+		for _, role := range getRoles() {
+			for _, user_count := range role.GetUserCounts() {
+				write_errors := writeCredentials(getCredentialSinks(), nil, write_journal, step_logger, role.GetHostUsers(), getDatabaseHostName(), getDatabasePortNumber(), getDatabaseName(), role.GetUsername(), passwords[role.GetUsername()+getUserCountAsString(user_count)], user_count, getRoleClientTLS(role), role.GetAuthenticationPlugin())
+				if write_errors != nil {
+					rollback_errors := write_journal.Rollback(step_logger, nil)
+					if rollback_errors != nil {
+						write_errors = append(write_errors, rollback_errors...)
+					}
+					return write_errors
+				}
+			}
+		}
+		return nil
+	}
+
+	// rotateCredentials gives every rotated user, pool members included, a new password of its own.
+	// a user that cannot be rotated keeps its old password and credential files and does not stop the others,
+	// all errors are returned together
+	rotateCredentials := func(roles ...string) []error {
+		var errors []error
+		rotation_targets, rotation_targets_errors := getRotationTargets(roles)
+		if rotation_targets_errors != nil {
+			return rotation_targets_errors
+		}
+
+		client, client_errors := getRootClient()
+		if client_errors != nil {
+			return client_errors
+		}
+
+		sql_command, sql_command_errors := getSQLCommand(client)
+		if sql_command_errors != nil {
+			return sql_command_errors
+		}
+
+		password_policy, password_policy_errors := getPasswordPolicy(sql_command)
+		if password_policy_errors != nil {
+			return password_policy_errors
+		}
+
+		step_logger := getStepLogger("rotate")
+		worker_pool := getWorkerPool(client)
+		for _, rotation_target := range rotation_targets {
+			step_logger.Info("rotating credentials", LOG_KEY_ROLE(), rotation_target.role, LOG_KEY_USER(), rotation_target.username+getUserCountAsString(rotation_target.user_count))
+			if rotation_target.authentication_plugin == AUTHENTICATION_PLUGIN_AUTH_SOCKET() {
+				step_logger.Info("skipping user without a password", LOG_KEY_ROLE(), rotation_target.role, LOG_KEY_USER(), rotation_target.username+getUserCountAsString(rotation_target.user_count), "authentication_plugin", rotation_target.authentication_plugin)
+				continue
+			}
+
+			target_exists_errors := validateRotationTargetExists(client, rotation_target)
+			if target_exists_errors != nil {
+				errors = append(errors, target_exists_errors...)
+				continue
+			}
+
+			password, password_errors := generatePassword(password_policy)
+			if password_errors != nil {
+				errors = append(errors, password_errors...)
+				continue
+			}
+
+			// the password hashes are read first so a failed host or credential write can put the old password back
+			worker_pool.Submit(func(worker_sql_command *SQLCommand) []error {
+				var errors []error
+				full_username := rotation_target.username + getUserCountAsString(rotation_target.user_count)
+				_, restore_authentication_sql, existing_accounts_errors := getExistingAccounts(worker_sql_command, []string{full_username})
+				if existing_accounts_errors != nil {
+					return existing_accounts_errors
+				}
+
+				for _, host_name := range rotation_target.host_names {
+					if _, found := restore_authentication_sql[getAccountName(full_username, host_name)]; !found {
+						errors = append(errors, fmt.Errorf("database user: %s does not exist, run install before rotating credentials", getAccountName(full_username, host_name)))
+					}
+				}
+
+				if len(errors) > 0 {
+					return errors
+				}
+
+				updatePassword := func(host_name string) []error {
+					update_password_sql, update_password_sql_errors := getUpdatePasswordSQL(full_username, host_name, password)
+					if update_password_sql_errors != nil {
+						return update_password_sql_errors
+					}
+					return executeSQL(worker_sql_command, update_password_sql)
+				}
+
+				restorePassword := func(host_name string) []error {
+					return executeSQL(worker_sql_command, restore_authentication_sql[getAccountName(full_username, host_name)])
+				}
+
+				return rotatePassword(step_logger, full_username, rotation_target.host_names, updatePassword, restorePassword, func(rotation_journal *InstallJournal) []error {
+					return writeCredentials(getCredentialSinks(), nil, rotation_journal, step_logger, rotation_target.host_usernames, getDatabaseHostName(), getDatabasePortNumber(), getDatabaseName(), rotation_target.username, password, rotation_target.user_count, rotation_target.client_tls, rotation_target.authentication_plugin)
+				})
+			})
+		}
+
+		worker_pool_errors := worker_pool.Wait()
+		if worker_pool_errors != nil {
+			errors = append(errors, worker_pool_errors...)
+		}
+
+		if len(errors) > 0 {
+			return errors
+		}
+
+		return nil
+	}
+
+	executeAlterUser := func(sql_command *SQLCommand, alter_user_sql string) []error {
+		var sql_builder strings.Builder
+		sql_builder.WriteString(alter_user_sql)
+		options := json.NewMapValue()
+		options.SetBoolValue("read_no_records", true)
+		_, alter_user_errors := sql_command.ExecuteUnsafeCommand(sql_builder, options)
+		return alter_user_errors
+	}
+
+	discardOldPasswords := func(roles ...string) []error {
+		rotation_targets, rotation_targets_errors := getRotationTargets(roles)
+		if rotation_targets_errors != nil {
+			return rotation_targets_errors
+		}
+
+		client, client_errors := getRootClient()
+		if client_errors != nil {
+			return client_errors
+		}
+
+		sql_command, sql_command_errors := getSQLCommand(client)
+		if sql_command_errors != nil {
+			return sql_command_errors
+		}
+
+		step_logger := getStepLogger("discard_old_passwords")
+		for _, rotation_target := range rotation_targets {
+			if rotation_target.authentication_plugin == AUTHENTICATION_PLUGIN_AUTH_SOCKET() {
+				step_logger.Info("skipping user without a password", LOG_KEY_ROLE(), rotation_target.role, LOG_KEY_USER(), rotation_target.username+getUserCountAsString(rotation_target.user_count), "authentication_plugin", rotation_target.authentication_plugin)
+				continue
+			}
+
+			for _, host_name := range rotation_target.host_names {
+				discard_sql, discard_sql_errors := getDiscardOldPasswordSQL(rotation_target.username+getUserCountAsString(rotation_target.user_count), host_name)
+				if discard_sql_errors != nil {
+					return discard_sql_errors
+				}
+
+				discard_errors := executeAlterUser(sql_command, discard_sql)
+				if discard_errors != nil {
+					return discard_errors
+				}
+			}
+			step_logger.Info("discarded old password", LOG_KEY_ROLE(), rotation_target.role, LOG_KEY_USER(), rotation_target.username+getUserCountAsString(rotation_target.user_count))
+		}
+
+		return nil
+	}
+
+	// rotateCredentialsWithRetainedPassword keeps the old password valid next to the new one so processes
+	// holding the old credential file keep working, the old password stays valid until DiscardOldPasswords
+	// is called once every service has restarted
+	rotateCredentialsWithRetainedPassword := func(roles ...string) []error {
+		var errors []error
+		rotation_targets, rotation_targets_errors := getRotationTargets(roles)
+		if rotation_targets_errors != nil {
+			return rotation_targets_errors
+		}
+
+		client, client_errors := getRootClient()
+		if client_errors != nil {
+			return client_errors
+		}
+
+		sql_command, sql_command_errors := getSQLCommand(client)
+		if sql_command_errors != nil {
+			return sql_command_errors
+		}
+
+		password_policy, password_policy_errors := getPasswordPolicy(sql_command)
+		if password_policy_errors != nil {
+			return password_policy_errors
+		}
+
+		step_logger := getStepLogger("rotate")
+		worker_pool := getWorkerPool(client)
+		for _, rotation_target := range rotation_targets {
+			step_logger.Info("rotating credentials retaining the current password", LOG_KEY_ROLE(), rotation_target.role, LOG_KEY_USER(), rotation_target.username+getUserCountAsString(rotation_target.user_count))
+			if rotation_target.authentication_plugin == AUTHENTICATION_PLUGIN_AUTH_SOCKET() {
+				step_logger.Info("skipping user without a password", LOG_KEY_ROLE(), rotation_target.role, LOG_KEY_USER(), rotation_target.username+getUserCountAsString(rotation_target.user_count), "authentication_plugin", rotation_target.authentication_plugin)
+				continue
+			}
+
+			target_exists_errors := validateRotationTargetExists(client, rotation_target)
+			if target_exists_errors != nil {
+				errors = append(errors, target_exists_errors...)
+				continue
+			}
+
+			password, password_errors := generatePassword(password_policy)
+			if password_errors != nil {
+				errors = append(errors, password_errors...)
+				continue
+			}
+
+			// the new credential files are written first and put back when the password cannot be changed, accounts
+			// already changed on other hosts keep the old password as their retained one so the old files still log in
+			worker_pool.Submit(func(worker_sql_command *SQLCommand) []error {
+				rotation_journal := newInstallJournal()
+				write_errors := writeCredentials(getCredentialSinks(), nil, rotation_journal, step_logger, rotation_target.host_usernames, getDatabaseHostName(), getDatabasePortNumber(), getDatabaseName(), rotation_target.username, password, rotation_target.user_count, rotation_target.client_tls, rotation_target.authentication_plugin)
+				if write_errors != nil {
+					rollback_errors := rotation_journal.Rollback(step_logger, nil)
+					return append(write_errors, rollback_errors...)
+				}
+
+				for _, host_name := range rotation_target.host_names {
+					retain_sql, retain_sql_errors := getRetainCurrentPasswordSQL(rotation_target.username+getUserCountAsString(rotation_target.user_count), host_name, password)
+					if retain_sql_errors != nil {
+						rollback_errors := rotation_journal.Rollback(step_logger, nil)
+						return append(retain_sql_errors, rollback_errors...)
+					}
+
+					retain_errors := executeAlterUser(worker_sql_command, retain_sql)
+					if retain_errors != nil {
+						rollback_errors := rotation_journal.Rollback(step_logger, nil)
+						return append(retain_errors, rollback_errors...)
+					}
+				}
+				return nil
+			})
+		}
+
+		worker_pool_errors := worker_pool.Wait()
+		if worker_pool_errors != nil {
+			errors = append(errors, worker_pool_errors...)
+		}
+
+		if len(errors) > 0 {
+			return errors
+		}
+
+		step_logger.Warn("old passwords retained, run discard-old-passwords once every service has restarted")
+		return nil
+	}
+
+	validate := func() []error {
+		var errors []error
+		temp_database_hostname := getDatabaseHostName()
+		temp_database_port_number := getDatabasePortNumber()
+		temp_database_name := getDatabaseName()
+		temp_database_username := getDatabaseRootUsername()
+		temp_database_password := getDatabaseRootPassword()
+
+		database_host_name_errors := verify.ValidateDomainName(temp_database_hostname)
+		if database_host_name_errors != nil {
+			errors = append(errors, database_host_name_errors...)
+		}
+
+		database_port_number_errors := verify.ValidatePortNumber(temp_database_port_number)
+		if database_port_number_errors != nil {
+			errors = append(errors, database_port_number_errors...)
+		}
+
+		database_name_errors := verify.ValidateDatabaseName(temp_database_name)
+		if database_name_errors != nil {
+			errors = append(errors, database_name_errors...)
+		}
+
+		username_errors := verify.ValidateUsername(temp_database_username)
+		if username_errors != nil {
+			errors = append(errors, username_errors...)
+		}
+
+		// the validator lists the offending characters, which would put part of the password in the logs
+		if temp_database_password != "" && verify.ValidateBase64Encoding(temp_database_password) != nil {
+			errors = append(errors, fmt.Errorf("root password must be base64 encoded, only letters, digits, +, / and = are allowed"))
+		}
+
+		character_set_errors := verify.ValidateCharacterSet(config.GetCharacterSet())
+		if character_set_errors != nil {
+			errors = append(errors, character_set_errors...)
+		}
+
+		collate_errors := verify.ValidateCollate(config.GetCollate())
+		if collate_errors != nil {
+			errors = append(errors, collate_errors...)
+		}
+
+		config_errors := config.Validate()
+		if config_errors != nil {
+			errors = append(errors, config_errors...)
+		}
+
+		for _, role := range getRoles() {
+			role_username_errors := verify.ValidateUsername(role.GetUsername())
+			if role_username_errors != nil {
+				errors = append(errors, role_username_errors...)
+			}
+
+			for _, table := range append(role.GetTables(), role.GetExcludedTables()...) {
+				if table == "*" {
+					continue
+				}
+
+				table_name_errors := verify.ValidateTableName(table)
+				if table_name_errors != nil {
+					errors = append(errors, table_name_errors...)
+				}
+			}
+
+			for _, column_privilege := range role.GetColumnPrivileges() {
+				table_name_errors := verify.ValidateTableName(column_privilege.GetTable())
+				if table_name_errors != nil {
+					errors = append(errors, table_name_errors...)
+				}
+
+				for _, column := range column_privilege.GetColumns() {
+					column_name_errors := verify.ValidateColumnName(column)
+					if column_name_errors != nil {
+						errors = append(errors, column_name_errors...)
+					}
+				}
+			}
+		}
+
+		if errors != nil {
+			return errors
+		}
+
+		return nil
+	}
+
+	// runInstall rolls a failed install back, this undoes every change journaled in the run and takes the users and
+	// global settings it completed out of the checkpoint again. so --resume only skips work after a run that was
+	// interrupted before it could roll back, or one that failed in dropRemovedUsers. a finished install removes the checkpoint
+	runInstall := func(install_report *InstallReport, install_checkpoint *InstallCheckpoint) (*InstallReport, []error) {
+		install_journal := newInstallJournal()
+		install_errors := install(install_report, install_journal, install_checkpoint)
+		if install_errors != nil && install_journal.Len() > 0 {
+			install_report.StartStep("rollback")
+			rollback_errors := install_journal.Rollback(getStepLogger("rollback"), install_report)
+			if rollback_errors != nil {
+				install_errors = append(install_errors, rollback_errors...)
+			}
+		} else if install_errors == nil {
+			install_errors = dropRemovedUsers(install_report, install_checkpoint)
+		}
+
+		if install_errors == nil {
+			remove_checkpoint_errors := install_checkpoint.Remove()
+			if remove_checkpoint_errors != nil {
+				install_errors = append(install_errors, remove_checkpoint_errors...)
+			}
+		}
+		install_report.Finish()
+		return install_report, install_errors
 	}
 
 	x := DatabaseInstaller{
 		Validate: func() []error {
-			return validate()
+			errors := validate()
+			root_password_errors := validateRootPasswordSet()
+			if root_password_errors != nil {
+				errors = append(errors, root_password_errors...)
+			}
+			return errors
+		},
+		Install: func() (*InstallReport, []error) {
+			install_report := newInstallReport()
+			checkpoint_file, checkpoint_file_errors := getCheckpointFile()
+			if checkpoint_file_errors != nil {
+				install_report.Finish()
+				return install_report, checkpoint_file_errors
+			}
+			return runInstall(install_report, newInstallCheckpoint(checkpoint_file, getDatabaseHostName(), getDatabasePortNumber(), getDatabaseName(), nil))
+		},
+		Plan: func() (*InstallPlan, []error) {
+			return plan()
+		},
+		Uninstall: func(confirm_database_name string, keep_data bool) []error {
+			return uninstall(confirm_database_name, keep_data)
+		},
+		WriteCredentials: func() []error {
+			return writeRootCredentialsFiles(nil, nil, getStepLogger("write_credentials"))
+		},
+		RotateCredentials: func(roles ...string) []error {
+			return rotateCredentials(roles...)
+		},
+		RotateCredentialsWithRetainedPassword: func(roles ...string) []error {
+			return rotateCredentialsWithRetainedPassword(roles...)
+		},
+		DiscardOldPasswords: func(roles ...string) []error {
+			return discardOldPasswords(roles...)
+		},
+		Reconcile: func(report_only bool) (*DriftReport, []error) {
+			return reconcile(report_only)
+		},
+		Status: func() (*InstallStatus, []error) {
+			return status()
+		},
+		Resume: func() (*InstallReport, []error) {
+			install_report := newInstallReport()
+			checkpoint_file, checkpoint_file_errors := getCheckpointFile()
+			if checkpoint_file_errors != nil {
+				install_report.Finish()
+				return install_report, checkpoint_file_errors
+			}
+
+			install_checkpoint, install_checkpoint_errors := loadInstallCheckpoint(checkpoint_file, getDatabaseHostName(), getDatabasePortNumber(), getDatabaseName())
+			if install_checkpoint_errors != nil {
+				install_report.Finish()
+				return install_report, install_checkpoint_errors
+			}
+			return runInstall(install_report, install_checkpoint)
+		},
+		ExportSQL: func(secrets_file string) (string, []error) {
+			return exportSQL(secrets_file)
 		},
-		Install: func() []error {
-			return install()
+		WriteExportedCredentials: func(secrets_file string) []error {
+			return writeExportedCredentials(secrets_file)
 		},
 	}
 
@@ -0,0 +1,108 @@
+package db_installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func getTestExportInstaller(t *testing.T, raw_roles string) *DatabaseInstaller {
+	config, config_errors := ParseInstallerConfig(`{
+		"version": 1,
+		"host": "127.0.0.1",
+		"port": 3306,
+		"database": {"name": "holistic"},
+		"root_username": "root",
+		"roles": {
+			"write": {"host_users": ["holisticxyz_w_"]},
+			"read": {"host_users": ["holisticxyz_r_"]},
+			"migration": {"host_users": ["holisticxyz_m_"]}` + raw_roles + `
+		}
+	}`)
+	if config_errors != nil {
+		t.Fatalf("ParseInstallerConfig returned errors: %s", config_errors)
+	}
+
+	database_installer, database_installer_errors := NewDatabaseInstallerFromConfig(config, nil)
+	if database_installer_errors != nil {
+		t.Fatalf("NewDatabaseInstallerFromConfig returned errors: %s", database_installer_errors)
+	}
+	return database_installer
+}
+
+func TestExportSQL(t *testing.T) {
+	database_installer := getTestExportInstaller(t, `,
+		"analytics": {"username": "holistic_a", "host_users": ["holisticxyz_a_"], "privileges": ["SELECT"]}`)
+	secrets_file := filepath.Join(t.TempDir(), "secrets.json")
+
+	// ExportSQL only writes the secrets file, the credential files wait for WriteExportedCredentials after the script is applied
+	script, export_errors := database_installer.ExportSQL(secrets_file)
+	if export_errors != nil {
+		t.Fatalf("ExportSQL returned errors: %s", export_errors)
+	}
+
+	for _, want := range []string{
+		"CREATE ROLE IF NOT EXISTS 'holistic_analytics_role'@'%';\n",
+		"GRANT SELECT ON `holistic`.* TO 'holistic_analytics_role'@'%';\n",
+		"CREATE USER IF NOT EXISTS 'holistic_a'@",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script does not contain %q", want)
+		}
+	}
+
+	passwords, passwords_errors := loadExportSecrets(secrets_file, "127.0.0.1", "3306", "holistic")
+	if passwords_errors != nil {
+		t.Fatalf("loadExportSecrets returned errors: %s", passwords_errors)
+	}
+
+	if passwords["holistic_a"] == "" || !strings.Contains(script, passwords["holistic_a"]) {
+		t.Errorf("the script and the secrets file do not share the password of holistic_a")
+	}
+
+	info, stat_error := os.Stat(secrets_file)
+	if stat_error != nil {
+		t.Fatalf("secrets file was not written: %s", stat_error)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("secrets file mode: got %o, want 600", info.Mode().Perm())
+	}
+}
+
+func TestExportSQLRefusesTableRoles(t *testing.T) {
+	tests := []struct {
+		name       string
+		raw_roles  string
+		want_error string
+	}{
+		{"tables", `, "analytics": {"username": "holistic_a", "host_users": ["holisticxyz_a_"], "privileges": ["SELECT"], "tables": ["orders"]}`, "export: role analytics is granted single tables"},
+		{"exclude tables", `, "analytics": {"username": "holistic_a", "host_users": ["holisticxyz_a_"], "privileges": ["SELECT"], "exclude_tables": ["secrets"]}`, "export: role analytics is granted single tables"},
+		{"column privileges", `, "analytics": {"username": "holistic_a", "host_users": ["holisticxyz_a_"], "column_privileges": [{"table": "customers", "privileges": ["SELECT"], "columns": ["id"]}]}`, "export: role analytics is granted columns"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			database_installer := getTestExportInstaller(t, test.raw_roles)
+			secrets_file := filepath.Join(t.TempDir(), "secrets.json")
+			script, export_errors := database_installer.ExportSQL(secrets_file)
+			if export_errors == nil {
+				t.Fatalf("ExportSQL returned a script, want errors")
+			}
+
+			if script != "" {
+				t.Errorf("ExportSQL returned a script next to its errors")
+			}
+
+			if !strings.Contains(fmt.Sprintf("%s", export_errors), test.want_error) {
+				t.Errorf("got errors %s, want %q", export_errors, test.want_error)
+			}
+
+			if _, stat_error := os.Stat(secrets_file); !os.IsNotExist(stat_error) {
+				t.Errorf("secrets file was written for a refused export")
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package db_installer
+
+import (
+	common "github.com/matehaxor03/holistic_common/common"
+)
+
+// NewDotenvCredentialSink writes one dotenv file per credential into directory
+func NewDotenvCredentialSink(directory string) (*CredentialSink, []error) {
+	return newDirectoryCredentialSink("dotenv", directory, ".env", func(credential DatabaseCredential) (string, []error) {
+		dotenv := common.ENV_HOLISTIC_DATABASE_HOSTNAME() + "=" + credential.GetHostName() + "\n" +
+			common.ENV_HOLISTIC_DATABASE_PORT_NUMBER() + "=" + credential.GetPortNumber() + "\n" +
+			common.ENV_HOLISTIC_DATABASE_NAME() + "=" + credential.GetDatabaseName() + "\n" +
+			ENV_HOLISTIC_DATABASE_USERNAME() + "=" + credential.GetUsername() + "\n"
+		if credential.HasPassword() {
+			dotenv += ENV_HOLISTIC_DATABASE_PASSWORD() + "=" + credential.GetPassword() + "\n"
+		}
+		return dotenv, nil
+	})
+}
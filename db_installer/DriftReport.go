@@ -0,0 +1,65 @@
+package db_installer
+
+import (
+	"fmt"
+	"strings"
+
+	json "github.com/matehaxor03/holistic_json/json"
+)
+
+type DriftReport struct {
+	AddDrift     func(role string, username string, host_name string, drift string, grant string, action string)
+	AddUser      func()
+	GetDrifts    func() []GrantDrift
+	HasDrift     func() bool
+	ToString     func() string
+	ToJSONString func(json *strings.Builder) []error
+}
+
+func newDriftReport(report_only bool) *DriftReport {
+	var drifts []GrantDrift
+	checked_users := 0
+
+	toString := func() string {
+		var report strings.Builder
+		for _, drift := range drifts {
+			report.WriteString(fmt.Sprintf("%-8s %-8s %-16s %s@%s %s\n", drift.GetAction(), drift.GetDrift(), drift.GetRole(), drift.GetUsername(), drift.GetHostName(), drift.GetGrant()))
+		}
+		report.WriteString(fmt.Sprintf("users checked: %d, drift: %d\n", checked_users, len(drifts)))
+		return report.String()
+	}
+
+	toJSONString := func(json_payload_builder *strings.Builder) []error {
+		drifts_array := json.NewArrayValue()
+		for _, drift := range drifts {
+			drifts_array.AppendMapValue(drift.GetMap())
+		}
+
+		report_map := json.NewMapValue()
+		report_map.SetBoolValue("report_only", report_only)
+		report_map.SetInt64Value("users_checked", int64(checked_users))
+		report_map.SetArrayValue("drift", drifts_array)
+		return report_map.ToJSONString(json_payload_builder)
+	}
+
+	return &DriftReport{
+		AddDrift: func(role string, username string, host_name string, drift string, grant string, action string) {
+			drifts = append(drifts, newGrantDrift(role, username, host_name, drift, grant, action))
+		},
+		AddUser: func() {
+			checked_users++
+		},
+		GetDrifts: func() []GrantDrift {
+			return drifts
+		},
+		HasDrift: func() bool {
+			return len(drifts) > 0
+		},
+		ToString: func() string {
+			return toString()
+		},
+		ToJSONString: func(json_payload_builder *strings.Builder) []error {
+			return toJSONString(json_payload_builder)
+		},
+	}
+}
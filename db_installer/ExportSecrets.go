@@ -0,0 +1,121 @@
+package db_installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	json "github.com/matehaxor03/holistic_json/json"
+)
+
+// loadExportSecrets reads the passwords of an exported install script by database username, a secrets file
+// that does not exist yet has none. the file only belongs to the host, port and database it was written for
+func loadExportSecrets(path string, host_name string, port_number string, database_name string) (map[string]string, []error) {
+	var errors []error
+	passwords := make(map[string]string)
+	raw_secrets, read_error := os.ReadFile(path)
+	if os.IsNotExist(read_error) {
+		return passwords, nil
+	} else if read_error != nil {
+		errors = append(errors, read_error)
+		return nil, errors
+	}
+
+	secrets_map, secrets_map_errors := json.Parse(strings.TrimSpace(string(raw_secrets)))
+	if secrets_map_errors != nil {
+		return nil, secrets_map_errors
+	}
+
+	for _, field := range [][2]string{{"host", host_name}, {"port", port_number}, {"database", database_name}} {
+		key, expected := field[0], field[1]
+		value, value_errors := getConfigString(secrets_map, "", key)
+		if value_errors != nil {
+			return nil, value_errors
+		}
+
+		if value == nil || *value != expected {
+			errors = append(errors, fmt.Errorf("secrets: %s was written for another %s, expected %s", path, key, expected))
+		}
+	}
+
+	if !secrets_map.IsMap("passwords") {
+		errors = append(errors, fmt.Errorf("secrets: %s passwords must be an object", path))
+	}
+
+	if len(errors) > 0 {
+		return nil, errors
+	}
+
+	passwords_map, passwords_map_errors := secrets_map.GetMap("passwords")
+	if passwords_map_errors != nil {
+		return nil, passwords_map_errors
+	}
+
+	for _, username := range passwords_map.GetKeys() {
+		password, password_errors := getConfigString(passwords_map, "passwords.", username)
+		if password_errors != nil {
+			errors = append(errors, password_errors...)
+			continue
+		}
+
+		if password == nil || *password == "" {
+			errors = append(errors, fmt.Errorf("secrets: %s password of %s is empty", path, username))
+			continue
+		}
+		passwords[username] = *password
+	}
+
+	if len(errors) > 0 {
+		return nil, errors
+	}
+
+	return passwords, nil
+}
+
+// saveExportSecrets writes a temporary file only the host user running the installer can read and renames it
+func saveExportSecrets(path string, host_name string, port_number string, database_name string, passwords map[string]string) []error {
+	var errors []error
+	var usernames []string
+	for username := range passwords {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+
+	passwords_map := json.NewMapValue()
+	for _, username := range usernames {
+		passwords_map.SetStringValue(username, passwords[username])
+	}
+
+	secrets := json.NewMapValue()
+	secrets.SetStringValue("host", host_name)
+	secrets.SetStringValue("port", port_number)
+	secrets.SetStringValue("database", database_name)
+	secrets.SetMapValue("passwords", passwords_map)
+
+	var json_payload strings.Builder
+	json_errors := secrets.ToJSONString(&json_payload)
+	if json_errors != nil {
+		return json_errors
+	}
+
+	mkdir_error := os.MkdirAll(filepath.Dir(path), 0700)
+	if mkdir_error != nil {
+		errors = append(errors, mkdir_error)
+		return errors
+	}
+
+	write_error := os.WriteFile(path+".tmp", []byte(json_payload.String()+"\n"), 0600)
+	if write_error != nil {
+		errors = append(errors, write_error)
+		return errors
+	}
+
+	rename_error := os.Rename(path+".tmp", path)
+	if rename_error != nil {
+		errors = append(errors, rename_error)
+		return errors
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package db_installer
+
+import (
+	json "github.com/matehaxor03/holistic_json/json"
+)
+
+// GrantDrift is one privilege a managed user holds without its role asking for it (extra)
+// or one its role asks for that the user does not hold (missing)
+type GrantDrift struct {
+	GetRole     func() string
+	GetUsername func() string
+	GetHostName func() string
+	GetDrift    func() string
+	GetGrant    func() string
+	GetAction   func() string
+	GetMap      func() json.Map
+}
+
+func newGrantDrift(role string, username string, host_name string, drift string, grant string, action string) GrantDrift {
+	getMap := func() json.Map {
+		drift_map := json.NewMapValue()
+		drift_map.SetStringValue("role", role)
+		drift_map.SetStringValue("username", username)
+		drift_map.SetStringValue("host", host_name)
+		drift_map.SetStringValue("drift", drift)
+		drift_map.SetStringValue("grant", grant)
+		drift_map.SetStringValue("action", action)
+		return drift_map
+	}
+
+	return GrantDrift{
+		GetRole: func() string {
+			return role
+		},
+		GetUsername: func() string {
+			return username
+		},
+		GetHostName: func() string {
+			return host_name
+		},
+		GetDrift: func() string {
+			return drift
+		},
+		GetGrant: func() string {
+			return grant
+		},
+		GetAction: func() string {
+			return action
+		},
+		GetMap: func() json.Map {
+			return getMap()
+		},
+	}
+}
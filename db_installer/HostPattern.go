@@ -0,0 +1,102 @@
+package db_installer
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	validation_constants "github.com/matehaxor03/holistic_validator/validation_constants"
+	validation_functions "github.com/matehaxor03/holistic_validator/validation_functions"
+)
+
+// normalizeHostPattern turns the shell style wildcard of app-*.internal into the % mysql matches hosts with
+func normalizeHostPattern(host_pattern string) string {
+	return strings.ReplaceAll(strings.TrimSpace(host_pattern), "*", "%")
+}
+
+func normalizeHostPatterns(host_patterns []string) []string {
+	var normalized_host_patterns []string
+	for _, host_pattern := range host_patterns {
+		normalized_host_patterns = append(normalized_host_patterns, normalizeHostPattern(host_pattern))
+	}
+	return normalized_host_patterns
+}
+
+// validateHostPattern accepts host names, ip addresses and the forms mysql matches client hosts with:
+// % and _ wildcards (10.0.%), a netmask (10.0.0.0/255.255.0.0) or a prefix length (10.0.0.0/16, mysql 8.0.23+).
+// mysql only matches ipv6 hosts as whole addresses and an ipv4 subnet only when its address has no host bits set
+func validateHostPattern(host_pattern string) []error {
+	var errors []error
+	if host_pattern == "" {
+		errors = append(errors, fmt.Errorf("host pattern is empty"))
+		return errors
+	}
+
+	if address, mask, is_subnet := strings.Cut(host_pattern, "/"); is_subnet {
+		ip := net.ParseIP(address)
+		if ip == nil || ip.To4() == nil {
+			errors = append(errors, fmt.Errorf("host pattern: %s must have an ipv4 address before the /", host_pattern))
+			return errors
+		}
+
+		var ip_mask net.IPMask
+		if _, prefix_length_error := strconv.Atoi(mask); prefix_length_error == nil {
+			_, network, cidr_error := net.ParseCIDR(host_pattern)
+			if cidr_error != nil {
+				errors = append(errors, fmt.Errorf("host pattern: %s is not a valid cidr", host_pattern))
+				return errors
+			}
+			ip_mask = network.Mask
+		} else {
+			netmask := net.ParseIP(mask)
+			if netmask == nil || netmask.To4() == nil {
+				errors = append(errors, fmt.Errorf("host pattern: %s must have a netmask or a prefix length after the /", host_pattern))
+				return errors
+			}
+
+			ip_mask = net.IPMask(netmask.To4())
+			if ones, bits := ip_mask.Size(); ones == 0 && bits == 0 {
+				errors = append(errors, fmt.Errorf("host pattern: %s netmask is not contiguous", host_pattern))
+				return errors
+			}
+		}
+
+		if !ip.To4().Mask(ip_mask).Equal(ip.To4()) {
+			errors = append(errors, fmt.Errorf("host pattern: %s has host bits set, use %s", host_pattern, ip.To4().Mask(ip_mask).String()+"/"+mask))
+			return errors
+		}
+		return nil
+	}
+
+	if strings.Contains(host_pattern, ":") {
+		ip := net.ParseIP(host_pattern)
+		if ip == nil || ip.To4() != nil {
+			errors = append(errors, fmt.Errorf("host pattern: %s is not an ipv6 address, ipv6 hosts cannot have wildcards", host_pattern))
+			return errors
+		}
+		return nil
+	}
+
+	valid_characters := validation_constants.GetValidDomainNameCharacters()
+	valid_characters["%"] = nil
+	whitelist_errors := validation_functions.WhitelistCharacters(valid_characters, host_pattern, "validateHostPattern", "host_pattern")
+	if whitelist_errors != nil {
+		errors = append(errors, whitelist_errors...)
+	}
+
+	if strings.HasPrefix(host_pattern, ".") || strings.HasPrefix(host_pattern, "-") || strings.HasSuffix(host_pattern, ".") || strings.Contains(host_pattern, "..") {
+		errors = append(errors, fmt.Errorf("host pattern: %s is not a valid host name", host_pattern))
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
+// getAccountName is how reports and logs name one account of a user, user@host
+func getAccountName(username string, host_name string) string {
+	return username + "@" + host_name
+}
@@ -0,0 +1,93 @@
+package db_installer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValidateHostPattern(t *testing.T) {
+	tests := []struct {
+		name         string
+		host_pattern string
+		want_error   string
+	}{
+		{"any host", "%", ""},
+		{"localhost", "localhost", ""},
+		{"host name", "db-1.example.com", ""},
+		{"upper case host name", "DB-1.Example.COM", ""},
+		{"underscore", "app_1", ""},
+		{"ipv4 address", "10.0.0.1", ""},
+		{"percent wildcard", "10.0.%", ""},
+		{"underscore wildcard", "10.0.0._", ""},
+		{"host name wildcard", "app-%.internal", ""},
+		{"netmask", "10.0.0.0/255.255.0.0", ""},
+		{"whole netmask", "10.0.0.1/255.255.255.255", ""},
+		{"prefix length", "10.0.0.0/16", ""},
+		{"zero prefix length", "0.0.0.0/0", ""},
+		{"ipv6 loopback", "::1", ""},
+		{"ipv6 address", "2001:db8::10", ""},
+		{"empty", "", "host pattern is empty"},
+		{"blank", " ", "invalid character"},
+		{"shell wildcard", "app-*.internal", "invalid character"},
+		{"single quotes", "'%'", "invalid character"},
+		{"double quotes", `"db"`, "invalid character"},
+		{"backquotes", "`db`", "invalid character"},
+		{"space", "db host", "invalid character"},
+		{"statement", "db';DROP USER root;--", "invalid character"},
+		{"leading dot", ".example.com", "is not a valid host name"},
+		{"leading dash", "-db.example.com", "is not a valid host name"},
+		{"trailing dot", "db.example.com.", "is not a valid host name"},
+		{"empty label", "db..example.com", "is not a valid host name"},
+		{"prefix length too long", "10.0.0.0/33", "is not a valid cidr"},
+		{"host bits with prefix length", "10.0.0.1/16", "has host bits set, use 10.0.0.0/16"},
+		{"host bits with netmask", "10.0.0.1/255.255.0.0", "has host bits set, use 10.0.0.0/255.255.0.0"},
+		{"netmask not contiguous", "10.0.0.0/255.0.255.0", "netmask is not contiguous"},
+		{"netmask missing", "10.0.0.0/", "must have a netmask or a prefix length after the /"},
+		{"address missing", "/16", "must have an ipv4 address before the /"},
+		{"wildcard subnet", "10.0.%/16", "must have an ipv4 address before the /"},
+		{"host name subnet", "db.example.com/16", "must have an ipv4 address before the /"},
+		{"ipv6 subnet", "2001:db8::/32", "must have an ipv4 address before the /"},
+		{"ipv6 wildcard", "2001:db8::%", "ipv6 hosts cannot have wildcards"},
+		{"ipv4 mapped ipv6", "::ffff:10.0.0.1", "is not an ipv6 address"},
+		{"not an ipv6 address", "2001:db8:::1", "is not an ipv6 address"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			host_pattern_errors := validateHostPattern(test.host_pattern)
+			if test.want_error == "" {
+				if host_pattern_errors != nil {
+					t.Errorf("validateHostPattern(%q) returned errors: %s", test.host_pattern, host_pattern_errors)
+				}
+				return
+			}
+
+			if host_pattern_errors == nil {
+				t.Fatalf("validateHostPattern(%q) returned no errors, want %q", test.host_pattern, test.want_error)
+			}
+
+			if !strings.Contains(fmt.Sprintf("%s", host_pattern_errors), test.want_error) {
+				t.Errorf("validateHostPattern(%q) returned %s, want %q", test.host_pattern, host_pattern_errors, test.want_error)
+			}
+		})
+	}
+}
+
+func TestNormalizeHostPattern(t *testing.T) {
+	for host_pattern, want := range map[string]string{
+		"app-*.internal": "app-%.internal",
+		" 10.0.* ":       "10.0.%",
+		"*":              "%",
+		"10.0.0.0/16":    "10.0.0.0/16",
+	} {
+		normalized := normalizeHostPattern(host_pattern)
+		if normalized != want {
+			t.Errorf("normalizeHostPattern(%q) = %q, want %q", host_pattern, normalized, want)
+		}
+
+		if validate_errors := validateHostPattern(normalized); validate_errors != nil {
+			t.Errorf("validateHostPattern(%q) returned errors: %s", normalized, validate_errors)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package db_installer
+
+import (
+	json "github.com/matehaxor03/holistic_json/json"
+)
+
+type InstallAction struct {
+	GetAction     func() string
+	GetObjectType func() string
+	GetName       func() string
+	GetDetail     func() string
+	GetMap        func() json.Map
+}
+
+func newInstallAction(action string, object_type string, name string, detail string) InstallAction {
+	getMap := func() json.Map {
+		action_map := json.NewMapValue()
+		action_map.SetStringValue("action", action)
+		action_map.SetStringValue("object_type", object_type)
+		action_map.SetStringValue("name", name)
+		action_map.SetStringValue("detail", detail)
+		return action_map
+	}
+
+	return InstallAction{
+		GetAction: func() string {
+			return action
+		},
+		GetObjectType: func() string {
+			return object_type
+		},
+		GetName: func() string {
+			return name
+		},
+		GetDetail: func() string {
+			return detail
+		},
+		GetMap: func() json.Map {
+			return getMap()
+		},
+	}
+}
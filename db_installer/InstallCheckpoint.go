@@ -0,0 +1,186 @@
+package db_installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	host_client "github.com/matehaxor03/holistic_host_client/host_client"
+	json "github.com/matehaxor03/holistic_json/json"
+)
+
+// InstallCheckpoint is the progress of an install kept in a local file, every completed step and
+// pool member is saved as soon as it is done so a resumed install skips them and keeps their passwords.
+// a rollback takes the entries of its run out again. a checkpoint only belongs to the host, port and database it was written for
+type InstallCheckpoint struct {
+	GetPath     func() string
+	IsCompleted func(key string) bool
+	Complete    func(key string) []error
+	Uncomplete  func(key string) []error
+	Remove      func() []error
+}
+
+func getCheckpointUserKey(role string, user_count int) string {
+	return "user " + role + " " + strconv.Itoa(user_count)
+}
+
+// getDefaultCheckpointFile is next to the root option files in ~/.db of the host user running the installer
+func getDefaultCheckpointFile(host_name string, port_number string, database_name string) (string, []error) {
+	var errors []error
+	host_client_instance, host_client_errors := host_client.NewHostClient()
+	if host_client_errors != nil {
+		return "", host_client_errors
+	}
+
+	host_user, host_user_errors := host_client_instance.Whoami()
+	if host_user_errors != nil {
+		return "", host_user_errors
+	}
+
+	db_directory, db_directory_errors := host_user.GetDirectoryDBAbsoluteDirectory()
+	if db_directory_errors != nil {
+		return "", db_directory_errors
+	} else if db_directory == nil {
+		errors = append(errors, fmt.Errorf("%s has no db directory", host_user.GetUsername()))
+		return "", errors
+	}
+	return filepath.Join(db_directory.GetPathAsString(), "holistic_db_install#"+host_name+"#"+port_number+"#"+database_name+".checkpoint"), nil
+}
+
+func newInstallCheckpoint(path string, host_name string, port_number string, database_name string, completed []string) *InstallCheckpoint {
+	lock := &sync.Mutex{}
+	completed_keys := make(map[string]bool)
+	for _, key := range completed {
+		completed_keys[key] = true
+	}
+
+	// save writes a temporary file and renames it so a crash never leaves half a checkpoint behind
+	save := func() []error {
+		var errors []error
+		completed_array := json.NewArrayValue()
+		for _, key := range completed {
+			completed_array.AppendStringValue(key)
+		}
+
+		checkpoint := json.NewMapValue()
+		checkpoint.SetStringValue("host", host_name)
+		checkpoint.SetStringValue("port", port_number)
+		checkpoint.SetStringValue("database", database_name)
+		checkpoint.SetArrayValue("completed", completed_array)
+
+		var json_payload strings.Builder
+		json_errors := checkpoint.ToJSONString(&json_payload)
+		if json_errors != nil {
+			return json_errors
+		}
+
+		mkdir_error := os.MkdirAll(filepath.Dir(path), 0700)
+		if mkdir_error != nil {
+			errors = append(errors, mkdir_error)
+			return errors
+		}
+
+		write_error := os.WriteFile(path+".tmp", []byte(json_payload.String()+"\n"), 0600)
+		if write_error != nil {
+			errors = append(errors, write_error)
+			return errors
+		}
+
+		rename_error := os.Rename(path+".tmp", path)
+		if rename_error != nil {
+			errors = append(errors, rename_error)
+			return errors
+		}
+		return nil
+	}
+
+	return &InstallCheckpoint{
+		GetPath: func() string {
+			return path
+		},
+		IsCompleted: func(key string) bool {
+			lock.Lock()
+			defer lock.Unlock()
+			return completed_keys[key]
+		},
+		Complete: func(key string) []error {
+			lock.Lock()
+			defer lock.Unlock()
+			if completed_keys[key] {
+				return nil
+			}
+			completed_keys[key] = true
+			completed = append(completed, key)
+			return save()
+		},
+		Uncomplete: func(key string) []error {
+			lock.Lock()
+			defer lock.Unlock()
+			if !completed_keys[key] {
+				return nil
+			}
+			delete(completed_keys, key)
+
+			var remaining []string
+			for _, completed_key := range completed {
+				if completed_key != key {
+					remaining = append(remaining, completed_key)
+				}
+			}
+			completed = remaining
+			return save()
+		},
+		Remove: func() []error {
+			var errors []error
+			remove_error := os.Remove(path)
+			if remove_error != nil && !os.IsNotExist(remove_error) {
+				errors = append(errors, remove_error)
+				return errors
+			}
+			return nil
+		},
+	}
+}
+
+func loadInstallCheckpoint(path string, host_name string, port_number string, database_name string) (*InstallCheckpoint, []error) {
+	var errors []error
+	raw_checkpoint, read_error := os.ReadFile(path)
+	if os.IsNotExist(read_error) {
+		errors = append(errors, fmt.Errorf("checkpoint: %s does not exist, run install without resume", path))
+		return nil, errors
+	} else if read_error != nil {
+		errors = append(errors, read_error)
+		return nil, errors
+	}
+
+	checkpoint_map, checkpoint_map_errors := json.Parse(strings.TrimSpace(string(raw_checkpoint)))
+	if checkpoint_map_errors != nil {
+		return nil, checkpoint_map_errors
+	}
+
+	for _, field := range [][2]string{{"host", host_name}, {"port", port_number}, {"database", database_name}} {
+		key, expected := field[0], field[1]
+		value, value_errors := getConfigString(checkpoint_map, "", key)
+		if value_errors != nil {
+			return nil, value_errors
+		}
+
+		if value == nil || *value != expected {
+			errors = append(errors, fmt.Errorf("checkpoint: %s was written for another %s, expected %s", path, key, expected))
+		}
+	}
+
+	completed, _, completed_errors := getConfigStrings(checkpoint_map, "", "completed")
+	if completed_errors != nil {
+		errors = append(errors, completed_errors...)
+	}
+
+	if len(errors) > 0 {
+		return nil, errors
+	}
+
+	return newInstallCheckpoint(path, host_name, port_number, database_name, completed), nil
+}
@@ -0,0 +1,48 @@
+package db_installer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallCheckpointUncomplete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "holistic_db_install#127.0.0.1#3306#holistic.checkpoint")
+	install_checkpoint := newInstallCheckpoint(path, "127.0.0.1", "3306", "holistic", []string{"global_settings"})
+	for _, key := range []string{getCheckpointUserKey(ROLE_WRITE(), 0), getCheckpointUserKey(ROLE_WRITE(), 1)} {
+		if complete_errors := install_checkpoint.Complete(key); complete_errors != nil {
+			t.Fatalf("Complete(%q) returned errors: %s", key, complete_errors)
+		}
+	}
+
+	// a rolled back user is taken out, the steps finished before it stay completed
+	if uncomplete_errors := install_checkpoint.Uncomplete(getCheckpointUserKey(ROLE_WRITE(), 1)); uncomplete_errors != nil {
+		t.Fatalf("Uncomplete returned errors: %s", uncomplete_errors)
+	}
+
+	if uncomplete_errors := install_checkpoint.Uncomplete("shrink_pools"); uncomplete_errors != nil {
+		t.Fatalf("Uncomplete of a key that is not completed returned errors: %s", uncomplete_errors)
+	}
+
+	loaded_checkpoint, load_errors := loadInstallCheckpoint(path, "127.0.0.1", "3306", "holistic")
+	if load_errors != nil {
+		t.Fatalf("loadInstallCheckpoint returned errors: %s", load_errors)
+	}
+
+	for key, want := range map[string]bool{
+		"global_settings":                     true,
+		getCheckpointUserKey(ROLE_WRITE(), 0): true,
+		getCheckpointUserKey(ROLE_WRITE(), 1): false,
+		"shrink_pools":                        false,
+	} {
+		if loaded_checkpoint.IsCompleted(key) != want {
+			t.Errorf("IsCompleted(%q) = %t, want %t", key, loaded_checkpoint.IsCompleted(key), want)
+		}
+	}
+
+	_, load_errors = loadInstallCheckpoint(path, "127.0.0.1", "3306", "other")
+	if !strings.Contains(fmt.Sprintf("%s", load_errors), "was written for another database, expected other") {
+		t.Errorf("got errors %s, want another database", load_errors)
+	}
+}
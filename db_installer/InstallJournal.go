@@ -0,0 +1,73 @@
+package db_installer
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// InstallJournal records the changes of one install together with how to undo them. Rollback undoes
+// them newest first and carries on past failures so a failed install leaves as little behind as it can
+type InstallJournal struct {
+	Record   func(change string, undo func() []error)
+	Len      func() int
+	Rollback func(logger *slog.Logger, install_report *InstallReport) []error
+}
+
+type installJournalEntry struct {
+	change string
+	undo   func() []error
+}
+
+func newInstallJournal() *InstallJournal {
+	lock := &sync.Mutex{}
+	var entries []installJournalEntry
+
+	rollback := func(logger *slog.Logger, install_report *InstallReport) []error {
+		var errors []error
+		for index := len(entries) - 1; index >= 0; index-- {
+			entry := entries[index]
+			undo_errors := entry.undo()
+			if undo_errors != nil {
+				logger.Error("could not roll back", "change", entry.change, "errors", fmt.Sprintf("%s", undo_errors))
+				for _, undo_error := range undo_errors {
+					errors = append(errors, fmt.Errorf("rollback of %s: %s", entry.change, undo_error))
+				}
+				if install_report != nil {
+					install_report.AddRollback(entry.change, "failed")
+				}
+				continue
+			}
+
+			logger.Info("rolled back", "change", entry.change)
+			if install_report != nil {
+				install_report.AddRollback(entry.change, "rolled-back")
+			}
+		}
+		entries = nil
+
+		if len(errors) > 0 {
+			return errors
+		}
+
+		return nil
+	}
+
+	return &InstallJournal{
+		Record: func(change string, undo func() []error) {
+			lock.Lock()
+			defer lock.Unlock()
+			entries = append(entries, installJournalEntry{change: change, undo: undo})
+		},
+		Len: func() int {
+			lock.Lock()
+			defer lock.Unlock()
+			return len(entries)
+		},
+		Rollback: func(logger *slog.Logger, install_report *InstallReport) []error {
+			lock.Lock()
+			defer lock.Unlock()
+			return rollback(logger, install_report)
+		},
+	}
+}
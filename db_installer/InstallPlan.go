@@ -0,0 +1,57 @@
+package db_installer
+
+import (
+	"fmt"
+	"strings"
+
+	json "github.com/matehaxor03/holistic_json/json"
+)
+
+type InstallPlan struct {
+	AddAction    func(action string, object_type string, name string, detail string)
+	GetActions   func() []InstallAction
+	ToString     func() string
+	ToJSONString func(json *strings.Builder) []error
+}
+
+func newInstallPlan() *InstallPlan {
+	var actions []InstallAction
+
+	toString := func() string {
+		var plan strings.Builder
+		for _, action := range actions {
+			plan.WriteString(fmt.Sprintf("%-16s %-16s %s", action.GetAction(), action.GetObjectType(), action.GetName()))
+			if action.GetDetail() != "" {
+				plan.WriteString(" (" + action.GetDetail() + ")")
+			}
+			plan.WriteString("\n")
+		}
+		return plan.String()
+	}
+
+	toJSONString := func(json_payload_builder *strings.Builder) []error {
+		actions_array := json.NewArrayValue()
+		for _, action := range actions {
+			actions_array.AppendMapValue(action.GetMap())
+		}
+
+		plan_map := json.NewMapValue()
+		plan_map.SetArrayValue("actions", actions_array)
+		return plan_map.ToJSONString(json_payload_builder)
+	}
+
+	return &InstallPlan{
+		AddAction: func(action string, object_type string, name string, detail string) {
+			actions = append(actions, newInstallAction(action, object_type, name, detail))
+		},
+		GetActions: func() []InstallAction {
+			return actions
+		},
+		ToString: func() string {
+			return toString()
+		},
+		ToJSONString: func(json_payload_builder *strings.Builder) []error {
+			return toJSONString(json_payload_builder)
+		},
+	}
+}
@@ -0,0 +1,261 @@
+package db_installer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	json "github.com/matehaxor03/holistic_json/json"
+)
+
+type InstallReport struct {
+	SetDatabase               func(database_name string, created bool)
+	AddUser                   func(role string, username string, action string)
+	AddGrant                  func(role string, username string, grant string)
+	AddRevoke                 func(role string, username string, grant string)
+	AddFile                   func(sink string, location string, owner string, action string)
+	AddGlobalSetting          func(name string, value string)
+	AddRollback               func(change string, status string)
+	SetDatabaseMigrationTable func(status string)
+	SetDatabaseMigrationSeed  func(status string)
+	StartStep                 func(step string)
+	Finish                    func()
+	ToString                  func() string
+	ToJSONString              func(json *strings.Builder) []error
+}
+
+type installReportStep struct {
+	name       string
+	started_at time.Time
+	duration   time.Duration
+}
+
+func newInstallReport() *InstallReport {
+	lock := &sync.Mutex{}
+	started_at := time.Now().UTC()
+	var finished_at *time.Time
+	var steps []installReportStep
+
+	database_name := ""
+	database_status := ""
+	database_migration_table_status := ""
+	database_migration_seed_status := ""
+	var role_names []string
+	role_users := make(map[string]map[string][]string)
+	role_grants := make(map[string][]json.Map)
+	role_revokes := make(map[string][]json.Map)
+	var files []json.Map
+	var rollbacks []json.Map
+	global_settings := json.NewMapValue()
+	var global_setting_names []string
+
+	getRole := func(role string) map[string][]string {
+		if _, found := role_users[role]; !found {
+			role_names = append(role_names, role)
+			role_users[role] = make(map[string][]string)
+		}
+		return role_users[role]
+	}
+
+	finishStep := func(now time.Time) {
+		if len(steps) > 0 && steps[len(steps)-1].duration == 0 {
+			steps[len(steps)-1].duration = now.Sub(steps[len(steps)-1].started_at)
+		}
+	}
+
+	getMap := func() json.Map {
+		report := json.NewMapValue()
+
+		database := json.NewMapValue()
+		database.SetStringValue("name", database_name)
+		database.SetStringValue("status", database_status)
+		report.SetMapValue("database", database)
+
+		roles := json.NewMapValue()
+		for _, role_name := range role_names {
+			role := json.NewMapValue()
+			for _, action := range [...]string{"created", "updated", "dropped"} {
+				usernames := json.NewArrayValue()
+				for _, username := range role_users[role_name][action] {
+					usernames.AppendStringValue(username)
+				}
+				role.SetArrayValue(action, usernames)
+			}
+
+			grants := json.NewArrayValue()
+			for _, grant := range role_grants[role_name] {
+				grants.AppendMapValue(grant)
+			}
+			role.SetArrayValue("granted", grants)
+
+			revokes := json.NewArrayValue()
+			for _, revoke := range role_revokes[role_name] {
+				revokes.AppendMapValue(revoke)
+			}
+			role.SetArrayValue("revoked", revokes)
+			roles.SetMapValue(role_name, role)
+		}
+		report.SetMapValue("roles", roles)
+
+		files_array := json.NewArrayValue()
+		for _, file := range files {
+			files_array.AppendMapValue(file)
+		}
+		report.SetArrayValue("files", files_array)
+
+		rollbacks_array := json.NewArrayValue()
+		for _, rollback := range rollbacks {
+			rollbacks_array.AppendMapValue(rollback)
+		}
+		report.SetArrayValue("rollback", rollbacks_array)
+		report.SetMapValue("global_settings", global_settings)
+
+		database_migration := json.NewMapValue()
+		database_migration.SetStringValue("table", database_migration_table_status)
+		database_migration.SetStringValue("seed", database_migration_seed_status)
+		report.SetMapValue("database_migration", database_migration)
+
+		timings := json.NewMapValue()
+		timings.SetStringValue("started_at", started_at.Format(time.RFC3339Nano))
+		if finished_at != nil {
+			timings.SetStringValue("finished_at", finished_at.Format(time.RFC3339Nano))
+			timings.SetInt64Value("duration_ms", finished_at.Sub(started_at).Milliseconds())
+		}
+
+		steps_array := json.NewArrayValue()
+		for _, step := range steps {
+			step_map := json.NewMapValue()
+			step_map.SetStringValue("name", step.name)
+			step_map.SetInt64Value("duration_ms", step.duration.Milliseconds())
+			steps_array.AppendMapValue(step_map)
+		}
+		timings.SetArrayValue("steps", steps_array)
+		report.SetMapValue("timings", timings)
+		return report
+	}
+
+	toString := func() string {
+		var summary strings.Builder
+		summary.WriteString(fmt.Sprintf("database %s: %s\n", database_name, database_status))
+		for _, role_name := range role_names {
+			summary.WriteString(fmt.Sprintf("role %s: %d created, %d updated, %d granted, %d revoked, %d dropped\n", role_name, len(role_users[role_name]["created"]), len(role_users[role_name]["updated"]), len(role_grants[role_name]), len(role_revokes[role_name]), len(role_users[role_name]["dropped"])))
+		}
+		summary.WriteString(fmt.Sprintf("files: %d\n", len(files)))
+		for _, global_setting_name := range global_setting_names {
+			value, _ := global_settings.GetStringValue(global_setting_name)
+			summary.WriteString(fmt.Sprintf("global %s: %s\n", global_setting_name, value))
+		}
+		summary.WriteString(fmt.Sprintf("DatabaseMigration table: %s, seed: %s\n", database_migration_table_status, database_migration_seed_status))
+		if len(rollbacks) > 0 {
+			failed := 0
+			for _, rollback := range rollbacks {
+				if status, _ := rollback.GetStringValue("status"); status == "failed" {
+					failed++
+				}
+			}
+			summary.WriteString(fmt.Sprintf("rollback: %d changes rolled back, %d failed\n", len(rollbacks)-failed, failed))
+		}
+		if finished_at != nil {
+			summary.WriteString(fmt.Sprintf("duration: %s\n", finished_at.Sub(started_at)))
+		}
+		return summary.String()
+	}
+
+	return &InstallReport{
+		SetDatabase: func(name string, created bool) {
+			lock.Lock()
+			defer lock.Unlock()
+			database_name = name
+			database_status = "existing"
+			if created {
+				database_status = "created"
+			}
+		},
+		AddUser: func(role string, username string, action string) {
+			lock.Lock()
+			defer lock.Unlock()
+			users := getRole(role)
+			users[action] = append(users[action], username)
+		},
+		AddGrant: func(role string, username string, grant string) {
+			lock.Lock()
+			defer lock.Unlock()
+			getRole(role)
+			grant_map := json.NewMapValue()
+			grant_map.SetStringValue("username", username)
+			grant_map.SetStringValue("grant", grant)
+			role_grants[role] = append(role_grants[role], grant_map)
+		},
+		AddRevoke: func(role string, username string, grant string) {
+			lock.Lock()
+			defer lock.Unlock()
+			getRole(role)
+			revoke_map := json.NewMapValue()
+			revoke_map.SetStringValue("username", username)
+			revoke_map.SetStringValue("grant", grant)
+			role_revokes[role] = append(role_revokes[role], revoke_map)
+		},
+		AddFile: func(sink string, location string, owner string, action string) {
+			lock.Lock()
+			defer lock.Unlock()
+			file := json.NewMapValue()
+			file.SetStringValue("sink", sink)
+			file.SetStringValue("location", location)
+			file.SetStringValue("owner", owner)
+			file.SetStringValue("action", action)
+			files = append(files, file)
+		},
+		AddGlobalSetting: func(name string, value string) {
+			lock.Lock()
+			defer lock.Unlock()
+			if !global_settings.HasKey(name) {
+				global_setting_names = append(global_setting_names, name)
+			}
+			global_settings.SetStringValue(name, value)
+		},
+		AddRollback: func(change string, status string) {
+			lock.Lock()
+			defer lock.Unlock()
+			rollback := json.NewMapValue()
+			rollback.SetStringValue("change", change)
+			rollback.SetStringValue("status", status)
+			rollbacks = append(rollbacks, rollback)
+		},
+		SetDatabaseMigrationTable: func(status string) {
+			lock.Lock()
+			defer lock.Unlock()
+			database_migration_table_status = status
+		},
+		SetDatabaseMigrationSeed: func(status string) {
+			lock.Lock()
+			defer lock.Unlock()
+			database_migration_seed_status = status
+		},
+		StartStep: func(step string) {
+			lock.Lock()
+			defer lock.Unlock()
+			now := time.Now().UTC()
+			finishStep(now)
+			steps = append(steps, installReportStep{name: step, started_at: now})
+		},
+		Finish: func() {
+			lock.Lock()
+			defer lock.Unlock()
+			now := time.Now().UTC()
+			finishStep(now)
+			finished_at = &now
+		},
+		ToString: func() string {
+			lock.Lock()
+			defer lock.Unlock()
+			return toString()
+		},
+		ToJSONString: func(json_payload_builder *strings.Builder) []error {
+			lock.Lock()
+			defer lock.Unlock()
+			report := getMap()
+			return report.ToJSONString(json_payload_builder)
+		},
+	}
+}
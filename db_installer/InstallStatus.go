@@ -0,0 +1,291 @@
+package db_installer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	json "github.com/matehaxor03/holistic_json/json"
+)
+
+// InstallStatus compares the server and the credential files with what install would create,
+// accounts are ok, missing (install would create them) or unexpected (install would drop them).
+// IsInstalled is false on any difference, also while the DatabaseMigration record has not reached desired,
+// verify exits with EXIT_CODE_DRIFT then
+type InstallStatus struct {
+	SetDatabase          func(database_name string, exists bool, character_set string, collate string)
+	AddAccount           func(role string, username string, host_name string, state string, grants []string)
+	AddCredentialFile    func(sink string, location string, owner string, username string, exists bool, login string, problems []string)
+	AddGlobalSetting     func(name string, value string, desired string)
+	SetDatabaseMigration func(table_exists bool, record_exists bool, current string, desired string)
+	GetDriftReport       func() *DriftReport
+	IsInstalled          func() bool
+	ToString             func() string
+	ToJSONString         func(json *strings.Builder) []error
+}
+
+type installStatusAccount struct {
+	role      string
+	username  string
+	host_name string
+	state     string
+	grants    []string
+}
+
+type installStatusFile struct {
+	sink     string
+	location string
+	owner    string
+	username string
+	exists   bool
+	login    string
+	problems []string
+}
+
+type installStatusGlobalSetting struct {
+	name    string
+	value   string
+	desired string
+}
+
+func newInstallStatus(expected_character_set string, expected_collate string) *InstallStatus {
+	database_name := ""
+	database_exists := false
+	character_set := ""
+	collate := ""
+	var accounts []installStatusAccount
+	var files []installStatusFile
+	var global_settings []installStatusGlobalSetting
+	database_migration_table_exists := false
+	database_migration_record_exists := false
+	database_migration_current := ""
+	database_migration_desired := ""
+	drift_report := newDriftReport(true)
+
+	// getGlobalSettingState ignores case and the order of comma separated values such as the sql_mode flags
+	getGlobalSettingState := func(global_setting installStatusGlobalSetting) string {
+		normalize := func(value string) string {
+			values := strings.Split(strings.ToUpper(value), ",")
+			sort.Strings(values)
+			return strings.Join(values, ",")
+		}
+
+		if normalize(global_setting.value) == normalize(global_setting.desired) {
+			return STATUS_OK()
+		}
+		return STATUS_DIFFERENT()
+	}
+
+	getDatabaseState := func() string {
+		if !database_exists {
+			return STATUS_MISSING()
+		}
+
+		if character_set != expected_character_set || collate != expected_collate {
+			return STATUS_DIFFERENT()
+		}
+		return STATUS_OK()
+	}
+
+	// getDatabaseMigrationState is different while the record has migrations to run, current has not reached desired
+	getDatabaseMigrationState := func() string {
+		if !database_migration_table_exists || !database_migration_record_exists {
+			return STATUS_MISSING()
+		}
+
+		if database_migration_current != database_migration_desired {
+			return STATUS_DIFFERENT()
+		}
+		return STATUS_OK()
+	}
+
+	isInstalled := func() bool {
+		if getDatabaseState() != STATUS_OK() || getDatabaseMigrationState() != STATUS_OK() || drift_report.HasDrift() {
+			return false
+		}
+
+		for _, account := range accounts {
+			if account.state != STATUS_OK() {
+				return false
+			}
+		}
+
+		for _, file := range files {
+			if !file.exists || file.login == LOGIN_FAILED() || len(file.problems) > 0 {
+				return false
+			}
+		}
+
+		for _, global_setting := range global_settings {
+			if getGlobalSettingState(global_setting) != STATUS_OK() {
+				return false
+			}
+		}
+		return true
+	}
+
+	getMap := func() json.Map {
+		status := json.NewMapValue()
+		status.SetBoolValue("installed", isInstalled())
+
+		database := json.NewMapValue()
+		database.SetStringValue("name", database_name)
+		database.SetStringValue("state", getDatabaseState())
+		database.SetStringValue("character_set", character_set)
+		database.SetStringValue("collate", collate)
+		database.SetStringValue("expected_character_set", expected_character_set)
+		database.SetStringValue("expected_collate", expected_collate)
+		status.SetMapValue("database", database)
+
+		accounts_array := json.NewArrayValue()
+		for _, account := range accounts {
+			account_map := json.NewMapValue()
+			account_map.SetStringValue("role", account.role)
+			account_map.SetStringValue("username", account.username)
+			account_map.SetStringValue("host", account.host_name)
+			account_map.SetStringValue("state", account.state)
+			grants := json.NewArrayValue()
+			for _, grant := range account.grants {
+				grants.AppendStringValue(grant)
+			}
+			account_map.SetArrayValue("grants", grants)
+			accounts_array.AppendMapValue(account_map)
+		}
+		status.SetArrayValue("accounts", accounts_array)
+
+		drift_array := json.NewArrayValue()
+		for _, drift := range drift_report.GetDrifts() {
+			drift_array.AppendMapValue(drift.GetMap())
+		}
+		status.SetArrayValue("drift", drift_array)
+
+		files_array := json.NewArrayValue()
+		for _, file := range files {
+			file_map := json.NewMapValue()
+			file_map.SetStringValue("sink", file.sink)
+			file_map.SetStringValue("location", file.location)
+			file_map.SetStringValue("owner", file.owner)
+			file_map.SetStringValue("username", file.username)
+			file_map.SetBoolValue("exists", file.exists)
+			file_map.SetStringValue("login", file.login)
+			problems := json.NewArrayValue()
+			for _, problem := range file.problems {
+				problems.AppendStringValue(problem)
+			}
+			file_map.SetArrayValue("problems", problems)
+			files_array.AppendMapValue(file_map)
+		}
+		status.SetArrayValue("files", files_array)
+
+		global_settings_array := json.NewArrayValue()
+		for _, global_setting := range global_settings {
+			global_setting_map := json.NewMapValue()
+			global_setting_map.SetStringValue("name", global_setting.name)
+			global_setting_map.SetStringValue("value", global_setting.value)
+			global_setting_map.SetStringValue("desired", global_setting.desired)
+			global_setting_map.SetStringValue("state", getGlobalSettingState(global_setting))
+			global_settings_array.AppendMapValue(global_setting_map)
+		}
+		status.SetArrayValue("global_settings", global_settings_array)
+
+		database_migration := json.NewMapValue()
+		database_migration.SetBoolValue("table_exists", database_migration_table_exists)
+		database_migration.SetBoolValue("record_exists", database_migration_record_exists)
+		database_migration.SetStringValue("current", database_migration_current)
+		database_migration.SetStringValue("desired", database_migration_desired)
+		database_migration.SetStringValue("state", getDatabaseMigrationState())
+		status.SetMapValue("database_migration", database_migration)
+		return status
+	}
+
+	toString := func() string {
+		var status strings.Builder
+		status.WriteString(fmt.Sprintf("%-10s %-18s %-10s %s\n", "database", database_name, getDatabaseState(), character_set+" "+collate))
+
+		for _, account := range accounts {
+			status.WriteString(fmt.Sprintf("%-10s %-18s %-10s %s", "account", account.role, account.state, getAccountName(account.username, account.host_name)))
+			if len(account.grants) > 0 {
+				status.WriteString(" (" + strings.Join(account.grants, "; ") + ")")
+			}
+			status.WriteString("\n")
+		}
+
+		for _, drift := range drift_report.GetDrifts() {
+			status.WriteString(fmt.Sprintf("%-10s %-18s %-10s %s %s\n", "grant", drift.GetRole(), drift.GetDrift(), getAccountName(drift.GetUsername(), drift.GetHostName()), drift.GetGrant()))
+		}
+
+		for _, file := range files {
+			state := STATUS_OK()
+			if !file.exists {
+				state = STATUS_MISSING()
+			} else if len(file.problems) > 0 {
+				state = STATUS_DIFFERENT()
+			}
+			status.WriteString(fmt.Sprintf("%-10s %-18s %-10s %s (login %s)", "file", file.sink, state, file.location, file.login))
+			if len(file.problems) > 0 {
+				status.WriteString(" " + strings.Join(file.problems, "; "))
+			}
+			status.WriteString("\n")
+		}
+
+		for _, global_setting := range global_settings {
+			status.WriteString(fmt.Sprintf("%-10s %-18s %-10s %s", "global", global_setting.name, getGlobalSettingState(global_setting), global_setting.value))
+			if getGlobalSettingState(global_setting) != STATUS_OK() {
+				status.WriteString(" (desired " + global_setting.desired + ")")
+			}
+			status.WriteString("\n")
+		}
+
+		if database_migration_table_exists && !database_migration_record_exists {
+			status.WriteString(fmt.Sprintf("%-10s %-18s %-10s no record\n", "table", "DatabaseMigration", STATUS_MISSING()))
+		} else if database_migration_table_exists {
+			status.WriteString(fmt.Sprintf("%-10s %-18s %-10s current %s, desired %s\n", "table", "DatabaseMigration", getDatabaseMigrationState(), database_migration_current, database_migration_desired))
+		} else {
+			status.WriteString(fmt.Sprintf("%-10s %-18s %-10s\n", "table", "DatabaseMigration", STATUS_MISSING()))
+		}
+
+		if isInstalled() {
+			status.WriteString("installed\n")
+		} else {
+			status.WriteString("not installed as configured\n")
+		}
+		return status.String()
+	}
+
+	return &InstallStatus{
+		SetDatabase: func(name string, exists bool, value_character_set string, value_collate string) {
+			database_name = name
+			database_exists = exists
+			character_set = value_character_set
+			collate = value_collate
+		},
+		AddAccount: func(role string, username string, host_name string, state string, grants []string) {
+			accounts = append(accounts, installStatusAccount{role: role, username: username, host_name: host_name, state: state, grants: grants})
+		},
+		AddCredentialFile: func(sink string, location string, owner string, username string, exists bool, login string, problems []string) {
+			files = append(files, installStatusFile{sink: sink, location: location, owner: owner, username: username, exists: exists, login: login, problems: problems})
+		},
+		AddGlobalSetting: func(name string, value string, desired string) {
+			global_settings = append(global_settings, installStatusGlobalSetting{name: name, value: value, desired: desired})
+		},
+		SetDatabaseMigration: func(table_exists bool, record_exists bool, current string, desired string) {
+			database_migration_table_exists = table_exists
+			database_migration_record_exists = record_exists
+			database_migration_current = current
+			database_migration_desired = desired
+		},
+		GetDriftReport: func() *DriftReport {
+			return drift_report
+		},
+		IsInstalled: func() bool {
+			return isInstalled()
+		},
+		ToString: func() string {
+			return toString()
+		},
+		ToJSONString: func(json_payload_builder *strings.Builder) []error {
+			status := getMap()
+			return status.ToJSONString(json_payload_builder)
+		},
+	}
+}
@@ -0,0 +1,39 @@
+package db_installer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInstallStatusDatabaseMigration(t *testing.T) {
+	tests := []struct {
+		name          string
+		table_exists  bool
+		record_exists bool
+		current       string
+		desired       string
+		want          bool
+		want_line     string
+	}{
+		{"in sync", true, true, "0", "0", true, "current 0, desired 0"},
+		{"current behind desired", true, true, "-1", "0", false, "different  current -1, desired 0"},
+		{"no record", true, false, "", "", false, "missing    no record"},
+		{"no table", false, false, "", "", false, "missing"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			install_status := newInstallStatus("utf8mb4", "utf8mb4_0900_ai_ci")
+			install_status.SetDatabase("holistic", true, "utf8mb4", "utf8mb4_0900_ai_ci")
+			install_status.SetDatabaseMigration(test.table_exists, test.record_exists, test.current, test.desired)
+
+			if install_status.IsInstalled() != test.want {
+				t.Errorf("IsInstalled() = %t, want %t", install_status.IsInstalled(), test.want)
+			}
+
+			if !strings.Contains(install_status.ToString(), test.want_line) {
+				t.Errorf("got status %q, want %q", install_status.ToString(), test.want_line)
+			}
+		})
+	}
+}
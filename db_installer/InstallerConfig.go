@@ -0,0 +1,990 @@
+package db_installer
+
+import (
+	encoding_json "encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	common "github.com/matehaxor03/holistic_common/common"
+	json "github.com/matehaxor03/holistic_json/json"
+	validation_constants "github.com/matehaxor03/holistic_validator/validation_constants"
+)
+
+// InstallerConfig describes one environment, it is built with defaults by NewInstallerConfig
+// and can be read from a json file of the form
+//
+//	{
+//	  "version": 1,
+//	  "host": "127.0.0.1",
+//	  "port": "3306",
+//	  "database": {"name": "holistic", "character_set": "utf8mb4", "collate": "utf8mb4_0900_ai_ci"},
+//	  "root_username": "root",
+//	  "tls": {"ca": "/etc/mysql/ca.pem", "cert": "/etc/mysql/installer-cert.pem", "key": "/etc/mysql/installer-key.pem"},
+//	  "password_generator": {"length": 40, "alphabet": "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789-_"},
+//	  "checkpoint_file": "/var/lib/holistic/install.checkpoint",
+//	  "concurrency": 16,
+//	  "roles": {
+//	    "migration": {"host_users": ["holisticmig"], "privileges": ["ALL"]},
+//	    "write": {"host_users": ["holisticw"], "pool_size": 100, "privileges": ["INSERT", "UPDATE", "SELECT"], "tls": {"require": "ssl"}},
+//	    "read": {"host_users": ["holisticr"], "pool_size": 100, "privileges": ["SELECT"], "exclude_tables": ["customers"],
+//	      "account_options": {"max_queries_per_hour": 100000, "max_user_connections": 10, "password_expire_interval_days": 90,
+//	        "failed_login_attempts": 5, "password_lock_time_days": 1},
+//	      "column_privileges": [{"table": "customers", "privileges": ["SELECT"], "columns": ["customer_id", "created_at"]}]},
+//	    "analytics": {"username": "holistic_a", "host_users": ["analyst"], "allowed_hosts": ["10.0.%", "app-*.internal"],
+//	      "privileges": ["SELECT"], "tables": ["orders"]},
+//	    "deleter": {"username": "holistic_d", "host_users": ["janitor"], "pool_size": 5, "privileges": ["SELECT", "DELETE"]},
+//	    "backup": {"username": "holistic_b", "host_users": ["backup"], "authentication_plugin": "auth_socket",
+//	      "allowed_hosts": ["localhost"], "privileges": ["SELECT", "LOCK TABLES"]},
+//	    "break_glass": {"username": "holistic_bg", "host_users": ["oncall"], "privileges": ["ALL"], "account_options": {"account_locked": true}}
+//	  },
+//	  "credential_sinks": ["option-file", "dotenv:/etc/holistic"],
+//	  "global_settings": [{"name": "time_zone", "value": "+00:00"}]
+//	}
+//
+// version is required and must be CONFIG_VERSION. roles other than migration, write and read need a username,
+// they get a pool of users when pool_size is set,
+// allowed_hosts are the client hosts the accounts of a role may connect from and default to the database host,
+// the top level tls is what the installer and the root option files connect with, roles without a tls of their own
+// get its ca and mode in their option files. tls.require (none, ssl, x509), issuer and subject restrict the accounts,
+// password_generator sets the length and alphabet of generated passwords, which must also pass the validate_password
+// policy of the server, checkpoint_file is where install keeps its progress for resume and defaults to a file in ~/.db,
+// concurrency is how many users install and rotate provision at the same time.
+// the root password is never read from the file so the file can be kept in version control
+type InstallerConfig struct {
+	GetDatabaseHostName     func() string
+	SetDatabaseHostName     func(database_host_name string)
+	GetDatabasePortNumber   func() string
+	SetDatabasePortNumber   func(database_port_number string)
+	GetDatabaseName         func() string
+	SetDatabaseName         func(database_name string)
+	GetCharacterSet         func() string
+	SetCharacterSet         func(character_set string)
+	GetCollate              func() string
+	SetCollate              func(collate string)
+	GetDatabaseRootUsername func() string
+	SetDatabaseRootUsername func(database_root_username string)
+	GetDatabaseRootPassword func() string
+	SetDatabaseRootPassword func(database_root_password string)
+	GetRoles                func() []*RoleDefinition
+	GetRole                 func(name string) *RoleDefinition
+	AddRole                 func(role *RoleDefinition)
+	GetCredentialSinks      func() []CredentialSink
+	SetCredentialSinks      func(credential_sinks []CredentialSink)
+	GetClientTLS            func() *ClientTLS
+	SetClientTLS            func(client_tls *ClientTLS)
+	GetPasswordGenerator    func() *PasswordGenerator
+	SetPasswordGenerator    func(password_generator *PasswordGenerator)
+	GetCheckpointFile       func() string
+	SetCheckpointFile       func(checkpoint_file string)
+	GetConcurrency          func() int
+	SetConcurrency          func(concurrency int)
+	GetGlobalSettingNames   func() []string
+	GetGlobalSetting        func(name string) string
+	SetGlobalSetting        func(name string, value string)
+	Validate                func() []error
+}
+
+func getBuiltInRoles() []string {
+	return []string{ROLE_MIGRATION(), ROLE_WRITE(), ROLE_READ()}
+}
+
+func NewInstallerConfig() *InstallerConfig {
+	database_host_name := ""
+	database_port_number := ""
+	database_name := ""
+	character_set := validation_constants.GET_CHARACTER_SET_UTF8MB4()
+	collate := validation_constants.GET_COLLATE_UTF8MB4_0900_AI_CI()
+	database_root_username := ""
+	database_root_password := ""
+	var credential_sinks []CredentialSink
+	var client_tls *ClientTLS
+	password_generator := NewPasswordGenerator(PASSWORD_LENGTH_DEFAULT(), PASSWORD_ALPHABET_DEFAULT(), nil)
+	checkpoint_file := ""
+	concurrency := CONCURRENCY_DEFAULT()
+
+	var roles []*RoleDefinition
+	roles = append(roles, NewRoleDefinition(ROLE_MIGRATION(), common.CONSTANT_HOLISTIC_DATABASE_MIGRATION_USERNAME(), []string{validation_constants.GRANT_ALL()}, nil, POOL_SIZE_NONE(), nil))
+	roles = append(roles, NewRoleDefinition(ROLE_WRITE(), common.CONSTANT_HOLISTIC_DATABASE_WRITE_USERNAME(), []string{validation_constants.GRANT_INSERT(), validation_constants.GRANT_UPDATE(), validation_constants.GRANT_SELECT()}, nil, POOL_SIZE_DEFAULT(), nil))
+	roles = append(roles, NewRoleDefinition(ROLE_READ(), common.CONSTANT_HOLISTIC_DATABASE_READ_USERNAME(), []string{validation_constants.GRANT_SELECT()}, nil, POOL_SIZE_DEFAULT(), nil))
+
+	global_setting_names := []string{"general_log", "time_zone", "sql_mode"}
+	global_settings := make(map[string]string)
+	global_settings["general_log"] = "OFF"
+	global_settings["time_zone"] = "+00:00"
+	global_settings["sql_mode"] = "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,ERROR_FOR_DIVISION_BY_ZERO,NO_ENGINE_SUBSTITUTION"
+
+	getRole := func(name string) *RoleDefinition {
+		for _, role := range roles {
+			if role.GetName() == name {
+				return role
+			}
+		}
+		return nil
+	}
+
+	validate := func() []error {
+		var errors []error
+		role_names := make(map[string]int)
+		for _, role := range roles {
+			role_names[role.GetName()]++
+			if role_names[role.GetName()] == 2 {
+				errors = append(errors, fmt.Errorf("role: %s is defined more than once", role.GetName()))
+			}
+
+			if role.GetName() == ROLE_ROOT() {
+				errors = append(errors, fmt.Errorf("role: %s is reserved for the database root user", ROLE_ROOT()))
+			}
+
+			if role.GetName() == "" || strings.Trim(role.GetName(), "abcdefghijklmnopqrstuvwxyz0123456789_-") != "" {
+				errors = append(errors, fmt.Errorf("role: %s must contain only lowercase letters, digits, underscores and dashes", role.GetName()))
+			}
+
+			role_errors := role.Validate()
+			if role_errors != nil {
+				errors = append(errors, role_errors...)
+			}
+		}
+
+		for _, role := range roles {
+			for _, other_role := range roles {
+				if role == other_role {
+					continue
+				}
+
+				if role.GetUsername() == other_role.GetUsername() {
+					if role.GetName() < other_role.GetName() {
+						errors = append(errors, fmt.Errorf("roles: %s and %s share the database username %s", role.GetName(), other_role.GetName(), role.GetUsername()))
+					}
+				} else if other_role.IsPooled() && strings.HasPrefix(role.GetUsername(), other_role.GetUsername()) {
+					errors = append(errors, fmt.Errorf("roles: %s database username %s starts with the %s pool prefix %s", role.GetName(), role.GetUsername(), other_role.GetName(), other_role.GetUsername()))
+				}
+
+				if role.GetUsername() == other_role.GetMySQLRoleName() {
+					errors = append(errors, fmt.Errorf("roles: %s database username %s is the mysql role of %s", role.GetName(), role.GetUsername(), other_role.GetName()))
+				}
+			}
+		}
+
+		if client_tls != nil {
+			client_tls_errors := client_tls.Validate()
+			if client_tls_errors != nil {
+				errors = append(errors, client_tls_errors...)
+			}
+		}
+
+		password_generator_errors := password_generator.Validate()
+		if password_generator_errors != nil {
+			errors = append(errors, password_generator_errors...)
+		}
+
+		if concurrency < CONCURRENCY_MINIMUM() || concurrency > CONCURRENCY_MAXIMUM() {
+			errors = append(errors, fmt.Errorf("concurrency: %d must be between %d and %d", concurrency, CONCURRENCY_MINIMUM(), CONCURRENCY_MAXIMUM()))
+		}
+
+		for _, global_setting_name := range global_setting_names {
+			if global_setting_name == "" || strings.Trim(global_setting_name, "abcdefghijklmnopqrstuvwxyz_") != "" {
+				errors = append(errors, fmt.Errorf("global setting: %s must contain only lowercase letters and underscores", global_setting_name))
+			}
+		}
+
+		if len(errors) > 0 {
+			return errors
+		}
+
+		return nil
+	}
+
+	return &InstallerConfig{
+		GetDatabaseHostName: func() string {
+			return database_host_name
+		},
+		SetDatabaseHostName: func(value string) {
+			database_host_name = value
+		},
+		GetDatabasePortNumber: func() string {
+			return database_port_number
+		},
+		SetDatabasePortNumber: func(value string) {
+			database_port_number = value
+		},
+		GetDatabaseName: func() string {
+			return database_name
+		},
+		SetDatabaseName: func(value string) {
+			database_name = value
+		},
+		GetCharacterSet: func() string {
+			return character_set
+		},
+		SetCharacterSet: func(value string) {
+			character_set = value
+		},
+		GetCollate: func() string {
+			return collate
+		},
+		SetCollate: func(value string) {
+			collate = value
+		},
+		GetDatabaseRootUsername: func() string {
+			return database_root_username
+		},
+		SetDatabaseRootUsername: func(value string) {
+			database_root_username = value
+		},
+		GetDatabaseRootPassword: func() string {
+			return database_root_password
+		},
+		SetDatabaseRootPassword: func(value string) {
+			database_root_password = value
+		},
+		GetRoles: func() []*RoleDefinition {
+			return roles
+		},
+		GetRole: func(name string) *RoleDefinition {
+			return getRole(name)
+		},
+		AddRole: func(role *RoleDefinition) {
+			roles = append(roles, role)
+		},
+		GetCredentialSinks: func() []CredentialSink {
+			return credential_sinks
+		},
+		SetCredentialSinks: func(value []CredentialSink) {
+			credential_sinks = value
+		},
+		GetClientTLS: func() *ClientTLS {
+			return client_tls
+		},
+		SetClientTLS: func(value *ClientTLS) {
+			client_tls = value
+		},
+		GetPasswordGenerator: func() *PasswordGenerator {
+			return password_generator
+		},
+		SetPasswordGenerator: func(value *PasswordGenerator) {
+			password_generator = value
+		},
+		GetCheckpointFile: func() string {
+			return checkpoint_file
+		},
+		SetCheckpointFile: func(value string) {
+			checkpoint_file = value
+		},
+		GetConcurrency: func() int {
+			return concurrency
+		},
+		SetConcurrency: func(value int) {
+			concurrency = value
+		},
+		GetGlobalSettingNames: func() []string {
+			return global_setting_names
+		},
+		GetGlobalSetting: func(name string) string {
+			return global_settings[name]
+		},
+		SetGlobalSetting: func(name string, value string) {
+			if _, found := global_settings[name]; !found {
+				global_setting_names = append(global_setting_names, name)
+			}
+			global_settings[name] = value
+		},
+		Validate: func() []error {
+			return validate()
+		},
+	}
+}
+
+func validateConfigKeys(config_map *json.Map, path string, allowed_keys ...string) []error {
+	var errors []error
+	for _, key := range config_map.GetKeys() {
+		found := false
+		for _, allowed_key := range allowed_keys {
+			if key == allowed_key {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			errors = append(errors, fmt.Errorf("config: %s%s is not a known setting", path, key))
+		}
+	}
+	return errors
+}
+
+func getConfigString(config_map *json.Map, path string, key string) (*string, []error) {
+	var errors []error
+	if !config_map.HasKey(key) {
+		return nil, nil
+	}
+
+	if !config_map.IsString(key) {
+		errors = append(errors, fmt.Errorf("config: %s%s must be a string", path, key))
+		return nil, errors
+	}
+
+	value, value_errors := config_map.GetStringValue(key)
+	if value_errors != nil {
+		return nil, value_errors
+	}
+	return &value, nil
+}
+
+func getConfigStrings(config_map *json.Map, path string, key string) ([]string, bool, []error) {
+	var errors []error
+	if !config_map.HasKey(key) {
+		return nil, false, nil
+	}
+
+	if !config_map.IsArray(key) {
+		errors = append(errors, fmt.Errorf("config: %s%s must be an array of strings", path, key))
+		return nil, false, errors
+	}
+
+	array, array_errors := config_map.GetArray(key)
+	if array_errors != nil {
+		return nil, false, array_errors
+	}
+
+	values, values_errors := array.GetArrayOfStringValue()
+	if values_errors != nil {
+		errors = append(errors, fmt.Errorf("config: %s%s must be an array of strings", path, key))
+		return nil, false, errors
+	}
+	return values, true, nil
+}
+
+func getConfigColumnPrivileges(role_map *json.Map, path string) ([]*ColumnPrivilege, []error) {
+	var errors []error
+	var column_privileges []*ColumnPrivilege
+	if !role_map.IsArray("column_privileges") {
+		errors = append(errors, fmt.Errorf("config: %scolumn_privileges must be an array of objects", path))
+		return nil, errors
+	}
+
+	column_privilege_array, column_privilege_array_errors := role_map.GetArray("column_privileges")
+	if column_privilege_array_errors != nil {
+		return nil, column_privilege_array_errors
+	}
+
+	for index := 0; index < column_privilege_array.Len(); index++ {
+		column_privilege_path := fmt.Sprintf("%scolumn_privileges[%d].", path, index)
+		column_privilege_map, column_privilege_map_errors := column_privilege_array.GetMap(index)
+		if column_privilege_map_errors != nil || column_privilege_map == nil {
+			errors = append(errors, fmt.Errorf("config: %scolumn_privileges[%d] must be an object", path, index))
+			continue
+		}
+
+		key_errors := validateConfigKeys(column_privilege_map, column_privilege_path, "table", "privileges", "columns")
+		if key_errors != nil {
+			errors = append(errors, key_errors...)
+		}
+
+		table, table_errors := getConfigString(column_privilege_map, column_privilege_path, "table")
+		if table_errors != nil {
+			errors = append(errors, table_errors...)
+		}
+
+		privileges, _, privileges_errors := getConfigStrings(column_privilege_map, column_privilege_path, "privileges")
+		if privileges_errors != nil {
+			errors = append(errors, privileges_errors...)
+		}
+
+		columns, _, columns_errors := getConfigStrings(column_privilege_map, column_privilege_path, "columns")
+		if columns_errors != nil {
+			errors = append(errors, columns_errors...)
+		}
+
+		if table == nil {
+			errors = append(errors, fmt.Errorf("config: %stable is required", column_privilege_path))
+			continue
+		}
+		column_privileges = append(column_privileges, NewColumnPrivilege(*table, privileges, columns))
+	}
+
+	if len(errors) > 0 {
+		return nil, errors
+	}
+
+	return column_privileges, nil
+}
+
+func getConfigAccountOptions(role_map *json.Map, path string) (*AccountOptions, []error) {
+	var errors []error
+	if !role_map.IsMap("account_options") {
+		errors = append(errors, fmt.Errorf("config: %saccount_options must be an object", path))
+		return nil, errors
+	}
+
+	account_options_map, account_options_map_errors := role_map.GetMap("account_options")
+	if account_options_map_errors != nil {
+		return nil, account_options_map_errors
+	}
+
+	account_options_path := path + "account_options."
+	account_options := NewAccountOptions()
+	integer_options := map[string]func(value int){
+		"max_queries_per_hour":          account_options.SetMaxQueriesPerHour,
+		"max_updates_per_hour":          account_options.SetMaxUpdatesPerHour,
+		"max_connections_per_hour":      account_options.SetMaxConnectionsPerHour,
+		"max_user_connections":          account_options.SetMaxUserConnections,
+		"password_expire_interval_days": account_options.SetPasswordExpireIntervalDays,
+		"failed_login_attempts":         account_options.SetFailedLoginAttempts,
+		"password_lock_time_days":       account_options.SetPasswordLockTimeDays,
+	}
+
+	allowed_keys := []string{"account_locked"}
+	for key := range integer_options {
+		allowed_keys = append(allowed_keys, key)
+	}
+	sort.Strings(allowed_keys)
+
+	key_errors := validateConfigKeys(account_options_map, account_options_path, allowed_keys...)
+	if key_errors != nil {
+		return nil, key_errors
+	}
+
+	for _, key := range allowed_keys {
+		setter, is_integer_option := integer_options[key]
+		if !is_integer_option || !account_options_map.HasKey(key) {
+			continue
+		}
+
+		if key == "password_lock_time_days" && account_options_map.IsString(key) {
+			value, value_errors := account_options_map.GetStringValue(key)
+			if value_errors != nil {
+				errors = append(errors, value_errors...)
+			} else if value != "unbounded" {
+				errors = append(errors, fmt.Errorf("config: %s%s must be a number of days or unbounded", account_options_path, key))
+			} else {
+				setter(PASSWORD_LOCK_TIME_UNBOUNDED())
+			}
+			continue
+		}
+
+		if !account_options_map.IsInteger(key) {
+			errors = append(errors, fmt.Errorf("config: %s%s must be a number", account_options_path, key))
+			continue
+		}
+
+		value, value_errors := account_options_map.GetIntValue(key)
+		if value_errors != nil {
+			errors = append(errors, value_errors...)
+			continue
+		}
+		setter(value)
+	}
+
+	if account_options_map.HasKey("account_locked") {
+		if !account_options_map.IsBool("account_locked") {
+			errors = append(errors, fmt.Errorf("config: %saccount_locked must be true or false", account_options_path))
+		} else {
+			account_locked, account_locked_errors := account_options_map.GetBool("account_locked")
+			if account_locked_errors != nil {
+				errors = append(errors, account_locked_errors...)
+			} else {
+				account_options.SetAccountLocked(account_locked)
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return nil, errors
+	}
+
+	return account_options, nil
+}
+
+// getConfigPasswordGenerator reads the length and alphabet of generated passwords, both default when left out
+func getConfigPasswordGenerator(config_map *json.Map) (*PasswordGenerator, []error) {
+	var errors []error
+	if !config_map.IsMap("password_generator") {
+		errors = append(errors, fmt.Errorf("config: password_generator must be an object"))
+		return nil, errors
+	}
+
+	password_generator_map, password_generator_map_errors := config_map.GetMap("password_generator")
+	if password_generator_map_errors != nil {
+		return nil, password_generator_map_errors
+	}
+
+	key_errors := validateConfigKeys(password_generator_map, "password_generator.", "length", "alphabet")
+	if key_errors != nil {
+		return nil, key_errors
+	}
+
+	length := PASSWORD_LENGTH_DEFAULT()
+	if password_generator_map.HasKey("length") {
+		if !password_generator_map.IsInteger("length") {
+			errors = append(errors, fmt.Errorf("config: password_generator.length must be a number"))
+		} else {
+			value, value_errors := password_generator_map.GetIntValue("length")
+			if value_errors != nil {
+				errors = append(errors, value_errors...)
+			} else {
+				length = value
+			}
+		}
+	}
+
+	alphabet := PASSWORD_ALPHABET_DEFAULT()
+	value, value_errors := getConfigString(password_generator_map, "password_generator.", "alphabet")
+	if value_errors != nil {
+		errors = append(errors, value_errors...)
+	} else if value != nil {
+		alphabet = *value
+	}
+
+	if len(errors) > 0 {
+		return nil, errors
+	}
+
+	return NewPasswordGenerator(length, alphabet, nil), nil
+}
+
+// getConfigTLS reads a tls object, require, issuer and subject are only read when requirement_keys is set
+func getConfigTLS(config_map *json.Map, path string, requirement_keys bool) (*TLSRequirement, *ClientTLS, []error) {
+	var errors []error
+	if !config_map.IsMap("tls") {
+		errors = append(errors, fmt.Errorf("config: %stls must be an object", path))
+		return nil, nil, errors
+	}
+
+	tls_map, tls_map_errors := config_map.GetMap("tls")
+	if tls_map_errors != nil {
+		return nil, nil, tls_map_errors
+	}
+
+	tls_path := path + "tls."
+	allowed_keys := []string{"ca", "cert", "key", "mode"}
+	if requirement_keys {
+		allowed_keys = append(allowed_keys, "require", "issuer", "subject")
+	}
+
+	key_errors := validateConfigKeys(tls_map, tls_path, allowed_keys...)
+	if key_errors != nil {
+		return nil, nil, key_errors
+	}
+
+	values := make(map[string]string)
+	for _, key := range allowed_keys {
+		value, value_errors := getConfigString(tls_map, tls_path, key)
+		if value_errors != nil {
+			errors = append(errors, value_errors...)
+		} else if value != nil {
+			values[key] = *value
+		}
+	}
+
+	if len(errors) > 0 {
+		return nil, nil, errors
+	}
+
+	var tls_requirement *TLSRequirement
+	if values["require"] != "" || values["issuer"] != "" || values["subject"] != "" {
+		tls_requirement = NewTLSRequirement(values["require"], values["issuer"], values["subject"])
+	}
+
+	var client_tls *ClientTLS
+	if values["ca"] != "" || values["cert"] != "" || values["key"] != "" || values["mode"] != "" {
+		client_tls = NewClientTLS(values["ca"], values["cert"], values["key"], values["mode"])
+	}
+	return tls_requirement, client_tls, nil
+}
+
+// validateJSONSyntax rejects malformed json before it is parsed, the json parser reads a key without a colon or
+// a value as if it were not there
+func validateJSONSyntax(name string, raw_json string) []error {
+	var errors []error
+	var value any
+	decode_error := encoding_json.Unmarshal([]byte(raw_json), &value)
+	if syntax_error, ok := decode_error.(*encoding_json.SyntaxError); ok {
+		errors = append(errors, fmt.Errorf("%s: invalid json at byte %d: %s", name, syntax_error.Offset, syntax_error))
+		return errors
+	} else if decode_error != nil {
+		errors = append(errors, fmt.Errorf("%s: invalid json: %s", name, decode_error))
+		return errors
+	}
+	return nil
+}
+
+func ParseInstallerConfig(raw_config string) (*InstallerConfig, []error) {
+	var errors []error
+	config := NewInstallerConfig()
+
+	syntax_errors := validateJSONSyntax("config", strings.TrimSpace(raw_config))
+	if syntax_errors != nil {
+		return nil, syntax_errors
+	}
+
+	config_map, config_map_errors := json.Parse(strings.TrimSpace(raw_config))
+	if config_map_errors != nil {
+		return nil, config_map_errors
+	}
+
+	// a config of another version may use keys this installer reads differently, nothing else is read from it
+	if !config_map.HasKey("version") {
+		errors = append(errors, fmt.Errorf("config: version is required, this installer reads version %d", CONFIG_VERSION()))
+		return nil, errors
+	}
+
+	if !config_map.IsInteger("version") {
+		errors = append(errors, fmt.Errorf("config: version must be a number"))
+		return nil, errors
+	}
+
+	version, version_errors := config_map.GetIntValue("version")
+	if version_errors != nil {
+		return nil, version_errors
+	}
+
+	if version != CONFIG_VERSION() {
+		errors = append(errors, fmt.Errorf("config: version %d is not supported, this installer reads version %d", version, CONFIG_VERSION()))
+		return nil, errors
+	}
+
+	key_errors := validateConfigKeys(config_map, "", "version", "host", "port", "database", "root_username", "tls", "password_generator", "checkpoint_file", "concurrency", "roles", "credential_sinks", "global_settings")
+	if key_errors != nil {
+		errors = append(errors, key_errors...)
+	}
+
+	host, host_errors := getConfigString(config_map, "", "host")
+	if host_errors != nil {
+		errors = append(errors, host_errors...)
+	} else if host != nil {
+		config.SetDatabaseHostName(*host)
+	}
+
+	if config_map.IsInteger("port") {
+		port, port_errors := config_map.GetIntValue("port")
+		if port_errors != nil {
+			errors = append(errors, port_errors...)
+		} else {
+			config.SetDatabasePortNumber(strconv.Itoa(port))
+		}
+	} else {
+		port, port_errors := getConfigString(config_map, "", "port")
+		if port_errors != nil {
+			errors = append(errors, port_errors...)
+		} else if port != nil {
+			config.SetDatabasePortNumber(*port)
+		}
+	}
+
+	root_username, root_username_errors := getConfigString(config_map, "", "root_username")
+	if root_username_errors != nil {
+		errors = append(errors, root_username_errors...)
+	} else if root_username != nil {
+		config.SetDatabaseRootUsername(*root_username)
+	}
+
+	if config_map.HasKey("tls") {
+		_, client_tls, client_tls_errors := getConfigTLS(config_map, "", false)
+		if client_tls_errors != nil {
+			errors = append(errors, client_tls_errors...)
+		} else {
+			config.SetClientTLS(client_tls)
+		}
+	}
+
+	if config_map.HasKey("password_generator") {
+		password_generator, password_generator_errors := getConfigPasswordGenerator(config_map)
+		if password_generator_errors != nil {
+			errors = append(errors, password_generator_errors...)
+		} else {
+			config.SetPasswordGenerator(password_generator)
+		}
+	}
+
+	checkpoint_file, checkpoint_file_errors := getConfigString(config_map, "", "checkpoint_file")
+	if checkpoint_file_errors != nil {
+		errors = append(errors, checkpoint_file_errors...)
+	} else if checkpoint_file != nil {
+		config.SetCheckpointFile(*checkpoint_file)
+	}
+
+	if config_map.HasKey("concurrency") {
+		if !config_map.IsInteger("concurrency") {
+			errors = append(errors, fmt.Errorf("config: concurrency must be a number"))
+		} else {
+			concurrency, concurrency_errors := config_map.GetIntValue("concurrency")
+			if concurrency_errors != nil {
+				errors = append(errors, concurrency_errors...)
+			} else {
+				config.SetConcurrency(concurrency)
+			}
+		}
+	}
+
+	if config_map.HasKey("database") {
+		if !config_map.IsMap("database") {
+			errors = append(errors, fmt.Errorf("config: database must be an object"))
+		} else {
+			database_map, database_map_errors := config_map.GetMap("database")
+			if database_map_errors != nil {
+				return nil, database_map_errors
+			}
+
+			database_key_errors := validateConfigKeys(database_map, "database.", "name", "character_set", "collate")
+			if database_key_errors != nil {
+				errors = append(errors, database_key_errors...)
+			}
+
+			name, name_errors := getConfigString(database_map, "database.", "name")
+			if name_errors != nil {
+				errors = append(errors, name_errors...)
+			} else if name != nil {
+				config.SetDatabaseName(*name)
+			}
+
+			character_set, character_set_errors := getConfigString(database_map, "database.", "character_set")
+			if character_set_errors != nil {
+				errors = append(errors, character_set_errors...)
+			} else if character_set != nil {
+				config.SetCharacterSet(*character_set)
+			}
+
+			collate, collate_errors := getConfigString(database_map, "database.", "collate")
+			if collate_errors != nil {
+				errors = append(errors, collate_errors...)
+			} else if collate != nil {
+				config.SetCollate(*collate)
+			}
+		}
+	}
+
+	if config_map.HasKey("roles") {
+		if !config_map.IsMap("roles") {
+			errors = append(errors, fmt.Errorf("config: roles must be an object"))
+		} else {
+			roles_map, roles_map_errors := config_map.GetMap("roles")
+			if roles_map_errors != nil {
+				return nil, roles_map_errors
+			}
+
+			// built in roles keep their order, other roles follow sorted by name
+			var custom_role_names []string
+			for _, role_name := range roles_map.GetKeys() {
+				if !containsString(getBuiltInRoles(), role_name) {
+					custom_role_names = append(custom_role_names, role_name)
+				}
+			}
+			sort.Strings(custom_role_names)
+
+			for _, role_name := range append(getBuiltInRoles(), custom_role_names...) {
+				if !roles_map.HasKey(role_name) {
+					continue
+				}
+
+				path := "roles." + role_name + "."
+				if !roles_map.IsMap(role_name) {
+					errors = append(errors, fmt.Errorf("config: roles.%s must be an object", role_name))
+					continue
+				}
+
+				role_map, role_map_errors := roles_map.GetMap(role_name)
+				if role_map_errors != nil {
+					return nil, role_map_errors
+				}
+
+				role := config.GetRole(role_name)
+				allowed_keys := []string{"host_users", "allowed_hosts", "tls", "authentication_plugin", "account_options", "privileges", "tables", "exclude_tables", "column_privileges"}
+				if role == nil {
+					allowed_keys = append(allowed_keys, "username", "pool_size")
+				} else if role.IsPooled() {
+					allowed_keys = append(allowed_keys, "pool_size")
+				}
+
+				role_key_errors := validateConfigKeys(role_map, path, allowed_keys...)
+				if role_key_errors != nil {
+					errors = append(errors, role_key_errors...)
+				}
+
+				if role == nil {
+					username, username_errors := getConfigString(role_map, path, "username")
+					if username_errors != nil {
+						errors = append(errors, username_errors...)
+						continue
+					} else if username == nil {
+						errors = append(errors, fmt.Errorf("config: %susername is required", path))
+						continue
+					}
+
+					// a pooled role starts out invalid so a pool_size that cannot be read fails validation
+					pool_size := POOL_SIZE_NONE()
+					if role_map.HasKey("pool_size") {
+						pool_size = POOL_SIZE_MINIMUM() - 1
+					}
+					role = NewRoleDefinition(role_name, *username, nil, nil, pool_size, nil)
+					config.AddRole(role)
+				}
+
+				host_users, host_users_found, host_users_errors := getConfigStrings(role_map, path, "host_users")
+				if host_users_errors != nil {
+					errors = append(errors, host_users_errors...)
+				} else if host_users_found {
+					role.SetHostUsers(host_users)
+				}
+
+				allowed_hosts, allowed_hosts_found, allowed_hosts_errors := getConfigStrings(role_map, path, "allowed_hosts")
+				if allowed_hosts_errors != nil {
+					errors = append(errors, allowed_hosts_errors...)
+				} else if allowed_hosts_found {
+					role.SetAllowedHosts(allowed_hosts)
+				}
+
+				authentication_plugin, authentication_plugin_errors := getConfigString(role_map, path, "authentication_plugin")
+				if authentication_plugin_errors != nil {
+					errors = append(errors, authentication_plugin_errors...)
+				} else if authentication_plugin != nil {
+					role.SetAuthenticationPlugin(*authentication_plugin)
+				}
+
+				if role_map.HasKey("account_options") {
+					account_options, account_options_errors := getConfigAccountOptions(role_map, path)
+					if account_options_errors != nil {
+						errors = append(errors, account_options_errors...)
+					} else {
+						role.SetAccountOptions(account_options)
+					}
+				}
+
+				if role_map.HasKey("tls") {
+					tls_requirement, client_tls, tls_errors := getConfigTLS(role_map, path, true)
+					if tls_errors != nil {
+						errors = append(errors, tls_errors...)
+					} else {
+						role.SetTLSRequirement(tls_requirement)
+						role.SetClientTLS(client_tls)
+					}
+				}
+
+				privileges, privileges_found, privileges_errors := getConfigStrings(role_map, path, "privileges")
+				if privileges_errors != nil {
+					errors = append(errors, privileges_errors...)
+				} else if privileges_found {
+					role.SetPrivileges(privileges)
+				}
+
+				tables, tables_found, tables_errors := getConfigStrings(role_map, path, "tables")
+				if tables_errors != nil {
+					errors = append(errors, tables_errors...)
+				} else if tables_found {
+					role.SetTables(tables)
+				}
+
+				excluded_tables, excluded_tables_found, excluded_tables_errors := getConfigStrings(role_map, path, "exclude_tables")
+				if excluded_tables_errors != nil {
+					errors = append(errors, excluded_tables_errors...)
+				} else if excluded_tables_found {
+					role.SetExcludedTables(excluded_tables)
+				}
+
+				if role_map.HasKey("column_privileges") {
+					column_privileges, column_privileges_errors := getConfigColumnPrivileges(role_map, path)
+					if column_privileges_errors != nil {
+						errors = append(errors, column_privileges_errors...)
+					}
+
+					for _, column_privilege := range column_privileges {
+						role.AddColumnPrivilege(column_privilege)
+					}
+				}
+
+				if role_map.HasKey("pool_size") && role.IsPooled() {
+					if !role_map.IsInteger("pool_size") {
+						errors = append(errors, fmt.Errorf("config: %spool_size must be a number", path))
+					} else {
+						pool_size, pool_size_errors := role_map.GetIntValue("pool_size")
+						if pool_size_errors != nil {
+							errors = append(errors, pool_size_errors...)
+						} else {
+							role.SetPoolSize(pool_size)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	raw_credential_sinks, raw_credential_sinks_found, raw_credential_sinks_errors := getConfigStrings(config_map, "", "credential_sinks")
+	if raw_credential_sinks_errors != nil {
+		errors = append(errors, raw_credential_sinks_errors...)
+	} else if raw_credential_sinks_found {
+		credential_sinks, credential_sinks_errors := ParseCredentialSinks(strings.Join(raw_credential_sinks, ","))
+		if credential_sinks_errors != nil {
+			errors = append(errors, credential_sinks_errors...)
+		} else {
+			config.SetCredentialSinks(credential_sinks)
+		}
+	}
+
+	if config_map.HasKey("global_settings") {
+		if !config_map.IsArray("global_settings") {
+			errors = append(errors, fmt.Errorf("config: global_settings must be an array of objects"))
+		} else {
+			global_settings, global_settings_errors := config_map.GetArray("global_settings")
+			if global_settings_errors != nil {
+				return nil, global_settings_errors
+			}
+
+			for index := 0; index < global_settings.Len(); index++ {
+				path := fmt.Sprintf("global_settings[%d].", index)
+				global_setting, global_setting_errors := global_settings.GetMap(index)
+				if global_setting_errors != nil || global_setting == nil {
+					errors = append(errors, fmt.Errorf("config: global_settings[%d] must be an object", index))
+					continue
+				}
+
+				global_setting_key_errors := validateConfigKeys(global_setting, path, "name", "value")
+				if global_setting_key_errors != nil {
+					errors = append(errors, global_setting_key_errors...)
+				}
+
+				name, name_errors := getConfigString(global_setting, path, "name")
+				if name_errors != nil {
+					errors = append(errors, name_errors...)
+				}
+
+				value, value_errors := getConfigString(global_setting, path, "value")
+				if value_errors != nil {
+					errors = append(errors, value_errors...)
+				}
+
+				if name == nil || value == nil {
+					errors = append(errors, fmt.Errorf("config: global_settings[%d] requires a name and a value", index))
+					continue
+				}
+				config.SetGlobalSetting(*name, *value)
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return nil, errors
+	}
+
+	return config, nil
+}
+
+func LoadInstallerConfig(path string) (*InstallerConfig, []error) {
+	var errors []error
+	raw_config, read_error := os.ReadFile(path)
+	if read_error != nil {
+		errors = append(errors, fmt.Errorf("config: %s could not be read: %s", path, read_error))
+		return nil, errors
+	}
+
+	config, config_errors := ParseInstallerConfig(string(raw_config))
+	if config_errors != nil {
+		for _, config_error := range config_errors {
+			errors = append(errors, fmt.Errorf("%s: %s", path, config_error))
+		}
+		return nil, errors
+	}
+
+	return config, nil
+}
@@ -0,0 +1,104 @@
+package db_installer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseInstallerConfig(t *testing.T) {
+	config, config_errors := ParseInstallerConfig(`{
+		"version": 1,
+		"host": "127.0.0.1",
+		"port": 3306,
+		"database": {"name": "holistic", "collate": "utf8mb4_0900_as_cs"},
+		"root_username": "root",
+		"concurrency": 4,
+		"roles": {
+			"write": {"host_users": ["holisticw"], "pool_size": 3},
+			"analytics": {"username": "holistic_a", "host_users": ["analyst"], "privileges": ["SELECT"], "tables": ["orders"]}
+		},
+		"global_settings": [{"name": "time_zone", "value": "-05:00"}]
+	}`)
+	if config_errors != nil {
+		t.Fatalf("ParseInstallerConfig returned errors: %s", config_errors)
+	}
+
+	for _, field := range [][2]string{
+		{config.GetDatabaseHostName(), "127.0.0.1"},
+		{config.GetDatabasePortNumber(), "3306"},
+		{config.GetDatabaseName(), "holistic"},
+		{config.GetCollate(), "utf8mb4_0900_as_cs"},
+		{config.GetDatabaseRootUsername(), "root"},
+		{config.GetGlobalSetting("time_zone"), "-05:00"},
+		{config.GetGlobalSetting("general_log"), "OFF"},
+	} {
+		if field[0] != field[1] {
+			t.Errorf("got %q, want %q", field[0], field[1])
+		}
+	}
+
+	if config.GetConcurrency() != 4 {
+		t.Errorf("concurrency: got %d, want 4", config.GetConcurrency())
+	}
+
+	if config.GetRole(ROLE_WRITE()).GetPoolSize() != 3 {
+		t.Errorf("write pool size: got %d, want 3", config.GetRole(ROLE_WRITE()).GetPoolSize())
+	}
+
+	analytics := config.GetRole("analytics")
+	if analytics == nil {
+		t.Fatalf("analytics role was not added")
+	}
+
+	if analytics.GetUsername() != "holistic_a" || analytics.IsPooled() || strings.Join(analytics.GetTables(), ",") != "orders" {
+		t.Errorf("analytics role: got username %s, pooled %t, tables %v", analytics.GetUsername(), analytics.IsPooled(), analytics.GetTables())
+	}
+}
+
+func TestParseInstallerConfigErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		raw_config string
+		want_error string
+	}{
+		{"truncated json", `{"version": 1, "host": "127.0.0.1"`, "config: invalid json at byte 34: unexpected end of JSON input"},
+		{"not json", `version: 1`, "config: invalid json at byte 1"},
+		{"not an object", `["version", 1]`, "json does not start with {"},
+		{"key without a colon", `{"version" 1}`, "config: invalid json at byte 12: invalid character '1' after object key"},
+		{"key without a value", `{"version": 1, "host": }`, "config: invalid json at byte 24: invalid character '}' looking for beginning of value"},
+		{"trailing comma", `{"version": 1,}`, "config: invalid json at byte 15"},
+		{"empty", ``, "config: invalid json at byte 0: unexpected end of JSON input"},
+		{"missing version", `{"host": "127.0.0.1"}`, "config: version is required, this installer reads version 1"},
+		{"version is a string", `{"version": "1"}`, "config: version must be a number"},
+		{"future version", `{"version": 2, "replicas": ["10.0.0.2"]}`, "config: version 2 is not supported, this installer reads version 1"},
+		{"old version", `{"version": 0}`, "config: version 0 is not supported, this installer reads version 1"},
+		{"unknown key", `{"version": 1, "hostname": "127.0.0.1"}`, "config: hostname is not a known setting"},
+		{"unknown database key", `{"version": 1, "database": {"name": "holistic", "engine": "innodb"}}`, "config: database.engine is not a known setting"},
+		{"unknown role key", `{"version": 1, "roles": {"read": {"pool": 5}}}`, "config: roles.read.pool is not a known setting"},
+		{"username on a built in role", `{"version": 1, "roles": {"write": {"username": "holistic_w"}}}`, "config: roles.write.username is not a known setting"},
+		{"custom role without username", `{"version": 1, "roles": {"analytics": {"host_users": ["analyst"]}}}`, "config: roles.analytics.username is required"},
+		{"column privilege without table", `{"version": 1, "roles": {"read": {"column_privileges": [{"privileges": ["SELECT"], "columns": ["id"]}]}}}`, "config: roles.read.column_privileges[0].table is required"},
+		{"global setting without value", `{"version": 1, "global_settings": [{"name": "time_zone"}]}`, "config: global_settings[0] requires a name and a value"},
+		{"host is a number", `{"version": 1, "host": 127}`, "config: host must be a string"},
+		{"pool size is a string", `{"version": 1, "roles": {"write": {"pool_size": "100"}}}`, "config: roles.write.pool_size must be a number"},
+		{"concurrency is a string", `{"version": 1, "concurrency": "8"}`, "config: concurrency must be a number"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, config_errors := ParseInstallerConfig(test.raw_config)
+			if config_errors == nil {
+				t.Fatalf("ParseInstallerConfig returned a config, want errors")
+			}
+
+			if config != nil {
+				t.Errorf("ParseInstallerConfig returned a config next to its errors")
+			}
+
+			if !strings.Contains(fmt.Sprintf("%s", config_errors), test.want_error) {
+				t.Errorf("got errors %s, want %q", config_errors, test.want_error)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package db_installer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// getKubernetesSecretName maps a credential name onto a DNS subdomain name as required for Secret names
+func getKubernetesSecretName(credential DatabaseCredential) string {
+	var secret_name strings.Builder
+	for _, character := range strings.ToLower(credential.GetName()) {
+		if (character >= 'a' && character <= 'z') || (character >= '0' && character <= '9') || character == '-' || character == '.' {
+			secret_name.WriteRune(character)
+		} else {
+			secret_name.WriteRune('-')
+		}
+	}
+
+	name := strings.Trim(secret_name.String(), "-.")
+	if len(name) > 253 {
+		name = strings.Trim(name[:253], "-.")
+	}
+	return name
+}
+
+// NewKubernetesSecretCredentialSink writes one Secret manifest per credential into directory for kubectl apply
+func NewKubernetesSecretCredentialSink(directory string, namespace string) (*CredentialSink, []error) {
+	var errors []error
+	if namespace == "" {
+		errors = append(errors, fmt.Errorf("kubernetes secret namespace is empty string"))
+		return nil, errors
+	}
+
+	return newDirectoryCredentialSink("kubernetes-secret", directory, ".yaml", func(credential DatabaseCredential) (string, []error) {
+		var manifest strings.Builder
+		manifest.WriteString("apiVersion: v1\n")
+		manifest.WriteString("kind: Secret\n")
+		manifest.WriteString("metadata:\n")
+		manifest.WriteString("  name: " + getKubernetesSecretName(credential) + "\n")
+		manifest.WriteString("  namespace: " + namespace + "\n")
+		manifest.WriteString("type: Opaque\n")
+		manifest.WriteString("stringData:\n")
+		manifest.WriteString(getCredentialYAML(credential, "  "))
+		return manifest.String(), nil
+	})
+}
@@ -0,0 +1,35 @@
+package db_installer
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// NewLogger builds a text or json slog logger that redacts password attributes
+func NewLogger(writer io.Writer, log_format string, log_level string) (*slog.Logger, []error) {
+	var errors []error
+	var level slog.Level
+	level_error := level.UnmarshalText([]byte(strings.ToUpper(log_level)))
+	if level_error != nil {
+		errors = append(errors, fmt.Errorf("log level: %s is not one of debug, info, warn or error", log_level))
+	}
+
+	options := &slog.HandlerOptions{Level: level, ReplaceAttr: RedactLogAttr}
+	var handler slog.Handler
+	switch log_format {
+	case LOG_FORMAT_TEXT():
+		handler = slog.NewTextHandler(writer, options)
+	case LOG_FORMAT_JSON():
+		handler = slog.NewJSONHandler(writer, options)
+	default:
+		errors = append(errors, fmt.Errorf("log format: %s is not one of %s or %s", log_format, LOG_FORMAT_TEXT(), LOG_FORMAT_JSON()))
+	}
+
+	if len(errors) > 0 {
+		return nil, errors
+	}
+
+	return slog.New(handler), nil
+}
@@ -0,0 +1,166 @@
+package db_installer
+
+import (
+	"fmt"
+	"os"
+
+	host_client "github.com/matehaxor03/holistic_host_client/host_client"
+)
+
+func getOptionFileContent(credential DatabaseCredential) (string, []error) {
+	client_section := "[client]\n" + "user=" + credential.GetUsername() + "\n"
+	if credential.HasPassword() {
+		client_section += "password=" + credential.GetPassword() + "\n"
+	} else {
+		client_section += "protocol=SOCKET\n"
+	}
+
+	// without tls caching_sha2_password sends the password encrypted with the server's public key
+	if credential.GetAuthenticationPlugin() == AUTHENTICATION_PLUGIN_CACHING_SHA2_PASSWORD() && credential.GetClientTLS() == nil {
+		client_section += "get-server-public-key=TRUE\n"
+	}
+
+	if credential.GetClientTLS() != nil {
+		client_section += credential.GetClientTLS().GetOptionFileLines()
+	}
+	return client_section + "[mysqld]\nskip-log-bin", nil
+}
+
+// NewOptionFileCredentialSink writes MySQL option files into <home>/.db of the host user the credential is for
+func NewOptionFileCredentialSink() (*CredentialSink, []error) {
+	host_client_instance, host_client_errors := host_client.NewHostClient()
+	if host_client_errors != nil {
+		return nil, host_client_errors
+	}
+
+	getCredentialsFile := func(credential DatabaseCredential) (*host_client.User, *host_client.AbsoluteDirectory, *host_client.AbsoluteFile, []error) {
+		host_user, host_user_errors := host_client_instance.User(credential.GetHostUsername())
+		if host_user_errors != nil {
+			return nil, nil, nil, host_user_errors
+		}
+
+		host_home_directory, host_home_directory_errors := host_user.GetHomeDirectoryAbsoluteDirectory()
+		if host_home_directory_errors != nil {
+			return nil, nil, nil, host_home_directory_errors
+		}
+
+		var db_creds_directory_path []string
+		db_creds_directory_path = append(db_creds_directory_path, host_home_directory.GetPath()...)
+		db_creds_directory_path = append(db_creds_directory_path, ".db")
+
+		db_creds_directory, db_creds_directory_errors := host_client_instance.AbsoluteDirectory(db_creds_directory_path)
+		if db_creds_directory_errors != nil {
+			return nil, nil, nil, db_creds_directory_errors
+		}
+
+		db_creds_file, db_creds_file_errors := host_client_instance.AbsoluteFile(*db_creds_directory, credential.GetName()+".config")
+		if db_creds_file_errors != nil {
+			return nil, nil, nil, db_creds_file_errors
+		}
+
+		return host_user, db_creds_directory, db_creds_file, nil
+	}
+
+	write := func(credential DatabaseCredential) []error {
+		var errors []error
+		host_user, db_creds_directory, db_creds_file, db_creds_file_errors := getCredentialsFile(credential)
+		if db_creds_file_errors != nil {
+			return db_creds_file_errors
+		}
+
+		db_creds_directory_create_errors := db_creds_directory.CreateIfDoesNotExist()
+		if db_creds_directory_create_errors != nil {
+			return db_creds_directory_create_errors
+		}
+
+		remove_db_file_if_exists_errors := db_creds_file.RemoveIfExists()
+		if remove_db_file_if_exists_errors != nil {
+			return remove_db_file_if_exists_errors
+		}
+
+		create_file_errors := db_creds_file.Create()
+		if create_file_errors != nil {
+			return create_file_errors
+		}
+
+		content, content_errors := getOptionFileContent(credential)
+		if content_errors != nil {
+			return content_errors
+		}
+
+		db_creds_file_append_errors := db_creds_file.Append(content)
+		if db_creds_file_append_errors != nil {
+			return db_creds_file_append_errors
+		}
+
+		chmod_error := os.Chmod(db_creds_file.GetPathAsString(), 0600)
+		if chmod_error != nil {
+			errors = append(errors, chmod_error)
+			return errors
+		}
+
+		user_primary_group, user_primary_group_errors := host_user.GetPrimaryGroup()
+		if user_primary_group_errors != nil {
+			return user_primary_group_errors
+		} else if user_primary_group == nil {
+			errors = append(errors, fmt.Errorf("primary group is nil"))
+			return errors
+		}
+
+		set_owner_errors := db_creds_file.SetOwner(*host_user, *user_primary_group)
+		if set_owner_errors != nil {
+			return set_owner_errors
+		}
+
+		set_directory_owner_errors := db_creds_directory.SetOwner(*host_user, *user_primary_group)
+		if set_directory_owner_errors != nil {
+			return set_directory_owner_errors
+		}
+
+		return nil
+	}
+
+	return &CredentialSink{
+		GetName: func() string {
+			return "option-file"
+		},
+		GetLocation: func(credential DatabaseCredential) (string, []error) {
+			_, _, db_creds_file, db_creds_file_errors := getCredentialsFile(credential)
+			if db_creds_file_errors != nil {
+				return "", db_creds_file_errors
+			}
+			return db_creds_file.GetPathAsString(), nil
+		},
+		Exists: func(credential DatabaseCredential) (bool, []error) {
+			_, _, db_creds_file, db_creds_file_errors := getCredentialsFile(credential)
+			if db_creds_file_errors != nil {
+				return false, db_creds_file_errors
+			}
+			return db_creds_file.Exists(), nil
+		},
+		Write: func(credential DatabaseCredential) []error {
+			return write(credential)
+		},
+		Remove: func(credential DatabaseCredential) []error {
+			_, _, db_creds_file, db_creds_file_errors := getCredentialsFile(credential)
+			if db_creds_file_errors != nil {
+				return db_creds_file_errors
+			}
+			return db_creds_file.RemoveIfExists()
+		},
+		Verify: func(credential DatabaseCredential) ([]string, []error) {
+			_, _, db_creds_file, db_creds_file_errors := getCredentialsFile(credential)
+			if db_creds_file_errors != nil {
+				return nil, db_creds_file_errors
+			}
+			return verifyCredentialFile(db_creds_file.GetPathAsString(), credential.GetHostUsername(), credential, getOptionFileContent)
+		},
+		Snapshot: func(credential DatabaseCredential) (func() []error, []error) {
+			_, _, db_creds_file, db_creds_file_errors := getCredentialsFile(credential)
+			if db_creds_file_errors != nil {
+				return nil, db_creds_file_errors
+			}
+			return snapshotCredentialFile(db_creds_file.GetPathAsString())
+		},
+	}, nil
+}
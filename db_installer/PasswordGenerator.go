@@ -0,0 +1,116 @@
+package db_installer
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// PasswordGenerator creates the passwords of the role accounts, every character is drawn uniformly
+// from alphabet using entropy, which is crypto/rand unless a caller passes a source of its own
+type PasswordGenerator struct {
+	GeneratePassword func() (string, []error)
+	GetLength        func() int
+	GetAlphabet      func() string
+	Validate         func() []error
+}
+
+func NewPasswordGenerator(length int, alphabet string, entropy io.Reader) *PasswordGenerator {
+	if entropy == nil {
+		entropy = rand.Reader
+	}
+	alphabet_runes := []rune(alphabet)
+
+	validate := func() []error {
+		var errors []error
+		if length < PASSWORD_LENGTH_MINIMUM() || length > PASSWORD_LENGTH_MAXIMUM() {
+			errors = append(errors, fmt.Errorf("password length: %d must be between %d and %d", length, PASSWORD_LENGTH_MINIMUM(), PASSWORD_LENGTH_MAXIMUM()))
+		}
+
+		if len(alphabet_runes) < 2 {
+			errors = append(errors, fmt.Errorf("password alphabet must have at least 2 characters"))
+		}
+
+		seen := make(map[rune]bool)
+		for _, character := range alphabet_runes {
+			if seen[character] {
+				errors = append(errors, fmt.Errorf("password alphabet: %q is listed more than once", character))
+			}
+			seen[character] = true
+
+			// these would need quoting in option files, dotenv files or sql
+			if strings.ContainsRune("'\"`\\#;$ \t\r\n", character) || character > 126 || character < 33 {
+				errors = append(errors, fmt.Errorf("password alphabet: %q is not allowed", character))
+			}
+		}
+
+		if len(errors) > 0 {
+			return errors
+		}
+
+		return nil
+	}
+
+	generatePassword := func() (string, []error) {
+		var errors []error
+		validate_errors := validate()
+		if validate_errors != nil {
+			return "", validate_errors
+		}
+
+		alphabet_size := big.NewInt(int64(len(alphabet_runes)))
+		var password strings.Builder
+		for index := 0; index < length; index++ {
+			alphabet_index, random_error := rand.Int(entropy, alphabet_size)
+			if random_error != nil {
+				errors = append(errors, fmt.Errorf("password generator could not read from its entropy source: %s", random_error))
+				return "", errors
+			}
+			password.WriteRune(alphabet_runes[alphabet_index.Int64()])
+		}
+		return password.String(), nil
+	}
+
+	return &PasswordGenerator{
+		GeneratePassword: func() (string, []error) {
+			return generatePassword()
+		},
+		GetLength: func() int {
+			return length
+		},
+		GetAlphabet: func() string {
+			return alphabet
+		},
+		Validate: func() []error {
+			return validate()
+		},
+	}
+}
+
+// generatePasswordForPolicy draws passwords until one meets password_policy, a nil policy takes the first one
+func generatePasswordForPolicy(password_generator *PasswordGenerator, password_policy *PasswordPolicy) (string, []error) {
+	var errors []error
+	for attempt := 0; attempt < PASSWORD_GENERATION_ATTEMPTS(); attempt++ {
+		password, password_errors := password_generator.GeneratePassword()
+		if password_errors != nil {
+			return "", password_errors
+		}
+
+		if password_policy == nil {
+			return password, nil
+		}
+
+		policy_errors := password_policy.Check(password)
+		if policy_errors == nil {
+			return password, nil
+		}
+
+		if attempt == PASSWORD_GENERATION_ATTEMPTS()-1 {
+			errors = append(errors, fmt.Errorf("password generator: %d characters from %q do not meet the validate_password policy of the server (%s) after %d attempts, change password_generator in the config", password_generator.GetLength(), password_generator.GetAlphabet(), password_policy.ToString(), PASSWORD_GENERATION_ATTEMPTS()))
+			errors = append(errors, policy_errors...)
+		}
+	}
+	return "", errors
+}
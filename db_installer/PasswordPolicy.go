@@ -0,0 +1,124 @@
+package db_installer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy is what the validate_password component of the server asks of new passwords,
+// generated passwords are checked against it before an account is created or changed
+type PasswordPolicy struct {
+	GetLength           func() int
+	GetMixedCaseCount   func() int
+	GetNumberCount      func() int
+	GetSpecialCharCount func() int
+	Check               func(password string) []error
+	ToString            func() string
+}
+
+func newPasswordPolicy(length int, mixed_case_count int, number_count int, special_char_count int) *PasswordPolicy {
+	check := func(password string) []error {
+		var errors []error
+		lower_count := 0
+		upper_count := 0
+		digit_count := 0
+		special_count := 0
+		for _, character := range password {
+			switch {
+			case unicode.IsLower(character):
+				lower_count++
+			case unicode.IsUpper(character):
+				upper_count++
+			case unicode.IsDigit(character):
+				digit_count++
+			default:
+				special_count++
+			}
+		}
+
+		// the password itself is never part of an error
+		if len(password) < length {
+			errors = append(errors, fmt.Errorf("validate_password: needs at least %d characters, the password has %d", length, len(password)))
+		}
+
+		if lower_count < mixed_case_count || upper_count < mixed_case_count {
+			errors = append(errors, fmt.Errorf("validate_password: needs at least %d lowercase and %d uppercase characters", mixed_case_count, mixed_case_count))
+		}
+
+		if digit_count < number_count {
+			errors = append(errors, fmt.Errorf("validate_password: needs at least %d digits", number_count))
+		}
+
+		if special_count < special_char_count {
+			errors = append(errors, fmt.Errorf("validate_password: needs at least %d special characters", special_char_count))
+		}
+
+		if len(errors) > 0 {
+			return errors
+		}
+
+		return nil
+	}
+
+	toString := func() string {
+		return fmt.Sprintf("length %d, mixed case %d, numbers %d, special characters %d", length, mixed_case_count, number_count, special_char_count)
+	}
+
+	return &PasswordPolicy{
+		GetLength: func() int {
+			return length
+		},
+		GetMixedCaseCount: func() int {
+			return mixed_case_count
+		},
+		GetNumberCount: func() int {
+			return number_count
+		},
+		GetSpecialCharCount: func() int {
+			return special_char_count
+		},
+		Check: func(password string) []error {
+			return check(password)
+		},
+		ToString: func() string {
+			return toString()
+		},
+	}
+}
+
+// newPasswordPolicyFromVariables reads SHOW GLOBAL VARIABLES LIKE 'validate_password%' by Variable_name, the component
+// names them validate_password.length and the older plugin validate_password_length. the LOW policy only checks the
+// length so the counts are left at 0 for it
+func newPasswordPolicyFromVariables(variables map[string]string) (*PasswordPolicy, []error) {
+	var errors []error
+	settings := make(map[string]string)
+	for variable_name, value := range variables {
+		settings[strings.TrimLeft(strings.TrimPrefix(variable_name, "validate_password"), "._")] = value
+	}
+
+	counts := make(map[string]int)
+	for _, setting_name := range []string{"length", "mixed_case_count", "number_count", "special_char_count"} {
+		value, found := settings[setting_name]
+		if !found {
+			continue
+		}
+
+		count, count_error := strconv.Atoi(value)
+		if count_error != nil {
+			errors = append(errors, fmt.Errorf("validate_password: %s is not a number: %s", setting_name, value))
+			continue
+		}
+		counts[setting_name] = count
+	}
+
+	if len(errors) > 0 {
+		return nil, errors
+	}
+
+	if policy := strings.ToUpper(settings["policy"]); policy == "LOW" || policy == "0" {
+		return newPasswordPolicy(counts["length"], 0, 0, 0), nil
+	}
+	return newPasswordPolicy(counts["length"], counts["mixed_case_count"], counts["number_count"], counts["special_char_count"]), nil
+}
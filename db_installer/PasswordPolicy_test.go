@@ -0,0 +1,188 @@
+package db_installer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// the defaults of the validate_password component of mysql 8 for each policy
+func getTestPasswordPolicyVariables(policy string) map[string]string {
+	return map[string]string{
+		"validate_password.check_user_name":    "ON",
+		"validate_password.dictionary_file":    "",
+		"validate_password.length":             "8",
+		"validate_password.mixed_case_count":   "1",
+		"validate_password.number_count":       "1",
+		"validate_password.policy":             policy,
+		"validate_password.special_char_count": "1",
+	}
+}
+
+func TestNewPasswordPolicyFromVariables(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables map[string]string
+		want      string
+	}{
+		{"low", getTestPasswordPolicyVariables("LOW"), "length 8, mixed case 0, numbers 0, special characters 0"},
+		{"low by number", getTestPasswordPolicyVariables("0"), "length 8, mixed case 0, numbers 0, special characters 0"},
+		{"medium", getTestPasswordPolicyVariables("MEDIUM"), "length 8, mixed case 1, numbers 1, special characters 1"},
+		{"strong", getTestPasswordPolicyVariables("STRONG"), "length 8, mixed case 1, numbers 1, special characters 1"},
+		{"plugin", map[string]string{
+			"validate_password_length":             "12",
+			"validate_password_mixed_case_count":   "2",
+			"validate_password_number_count":       "3",
+			"validate_password_policy":             "MEDIUM",
+			"validate_password_special_char_count": "4",
+		}, "length 12, mixed case 2, numbers 3, special characters 4"},
+		{"plugin low", map[string]string{"validate_password_length": "20", "validate_password_policy": "low", "validate_password_number_count": "3"}, "length 20, mixed case 0, numbers 0, special characters 0"},
+		{"missing counts", map[string]string{"validate_password.policy": "MEDIUM"}, "length 0, mixed case 0, numbers 0, special characters 0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			password_policy, password_policy_errors := newPasswordPolicyFromVariables(test.variables)
+			if password_policy_errors != nil {
+				t.Fatalf("newPasswordPolicyFromVariables returned errors: %s", password_policy_errors)
+			}
+
+			if password_policy.ToString() != test.want {
+				t.Errorf("got %q, want %q", password_policy.ToString(), test.want)
+			}
+		})
+	}
+
+	variables := getTestPasswordPolicyVariables("MEDIUM")
+	variables["validate_password.number_count"] = "one"
+	_, password_policy_errors := newPasswordPolicyFromVariables(variables)
+	if password_policy_errors == nil || !strings.Contains(fmt.Sprintf("%s", password_policy_errors), "validate_password: number_count is not a number: one") {
+		t.Errorf("got errors %s, want number_count is not a number", password_policy_errors)
+	}
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		want_error string
+	}{
+		{"meets the policy", "aB3-aB3-aB3-", ""},
+		{"too short", "aB3-", "needs at least 12 characters, the password has 4"},
+		{"one uppercase", "aB3-ab3-ab3-", "needs at least 2 lowercase and 2 uppercase characters"},
+		{"no lowercase", "AB3-AB3-AB3-", "needs at least 2 lowercase and 2 uppercase characters"},
+		{"one digit", "aB3-aBc-aBc-", "needs at least 2 digits"},
+		{"one special character", "aB3-aB3zaB3z", "needs at least 2 special characters"},
+	}
+
+	password_policy := newPasswordPolicy(12, 2, 2, 2)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			check_errors := password_policy.Check(test.password)
+			if test.want_error == "" {
+				if check_errors != nil {
+					t.Errorf("Check returned errors: %s", check_errors)
+				}
+				return
+			}
+
+			if !strings.Contains(fmt.Sprintf("%s", check_errors), test.want_error) {
+				t.Errorf("got errors %s, want %q", check_errors, test.want_error)
+			}
+
+			if strings.Contains(fmt.Sprintf("%s", check_errors), test.password) {
+				t.Errorf("errors %s contain the password", check_errors)
+			}
+		})
+	}
+}
+
+func TestGeneratePasswordForPolicy(t *testing.T) {
+	strong_variables := getTestPasswordPolicyVariables("STRONG")
+	strong_variables["validate_password.length"] = "32"
+	strong_variables["validate_password.mixed_case_count"] = "3"
+	strong_variables["validate_password.number_count"] = "3"
+	strong_variables["validate_password.special_char_count"] = "2"
+
+	tests := []struct {
+		name      string
+		variables map[string]string
+		generator *PasswordGenerator
+	}{
+		{"low", getTestPasswordPolicyVariables("LOW"), NewPasswordGenerator(PASSWORD_LENGTH_DEFAULT(), PASSWORD_ALPHABET_DEFAULT(), nil)},
+		{"low without special characters", getTestPasswordPolicyVariables("LOW"), NewPasswordGenerator(PASSWORD_LENGTH_MINIMUM(), "abcdefghijklmnopqrstuvwxyz", nil)},
+		{"medium", getTestPasswordPolicyVariables("MEDIUM"), NewPasswordGenerator(PASSWORD_LENGTH_DEFAULT(), PASSWORD_ALPHABET_DEFAULT(), nil)},
+		{"medium shortest", getTestPasswordPolicyVariables("MEDIUM"), NewPasswordGenerator(PASSWORD_LENGTH_MINIMUM(), PASSWORD_ALPHABET_DEFAULT(), nil)},
+		{"strong", getTestPasswordPolicyVariables("STRONG"), NewPasswordGenerator(PASSWORD_LENGTH_DEFAULT(), PASSWORD_ALPHABET_DEFAULT(), nil)},
+		{"strong raised counts", strong_variables, NewPasswordGenerator(PASSWORD_LENGTH_DEFAULT(), PASSWORD_ALPHABET_DEFAULT(), nil)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			password_policy, password_policy_errors := newPasswordPolicyFromVariables(test.variables)
+			if password_policy_errors != nil {
+				t.Fatalf("newPasswordPolicyFromVariables returned errors: %s", password_policy_errors)
+			}
+
+			for attempt := 0; attempt < 200; attempt++ {
+				password, password_errors := generatePasswordForPolicy(test.generator, password_policy)
+				if password_errors != nil {
+					t.Fatalf("generatePasswordForPolicy returned errors: %s", password_errors)
+				}
+
+				if len(password) != test.generator.GetLength() || len(password) < password_policy.GetLength() {
+					t.Fatalf("password has %d characters, want %d and at least %d", len(password), test.generator.GetLength(), password_policy.GetLength())
+				}
+
+				if check_errors := password_policy.Check(password); check_errors != nil {
+					t.Fatalf("generated password does not meet the policy: %s", check_errors)
+				}
+
+				for _, character := range password {
+					if !strings.ContainsRune(test.generator.GetAlphabet(), character) {
+						t.Fatalf("generated password has %q, which is not in the alphabet", character)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratePasswordForPolicyErrors(t *testing.T) {
+	long_variables := getTestPasswordPolicyVariables("MEDIUM")
+	long_variables["validate_password.length"] = "64"
+
+	tests := []struct {
+		name       string
+		variables  map[string]string
+		generator  *PasswordGenerator
+		want_error string
+	}{
+		{"alphabet without special characters", getTestPasswordPolicyVariables("MEDIUM"), NewPasswordGenerator(PASSWORD_LENGTH_DEFAULT(), "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789", nil), "needs at least 1 special characters"},
+		{"alphabet without digits", getTestPasswordPolicyVariables("STRONG"), NewPasswordGenerator(PASSWORD_LENGTH_DEFAULT(), "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ-_", nil), "needs at least 1 digits"},
+		{"shorter than the policy", long_variables, NewPasswordGenerator(PASSWORD_LENGTH_DEFAULT(), PASSWORD_ALPHABET_DEFAULT(), nil), "needs at least 64 characters, the password has 32"},
+		{"invalid generator", getTestPasswordPolicyVariables("LOW"), NewPasswordGenerator(PASSWORD_LENGTH_DEFAULT(), "ab'", nil), "password alphabet: '\\'' is not allowed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			password_policy, password_policy_errors := newPasswordPolicyFromVariables(test.variables)
+			if password_policy_errors != nil {
+				t.Fatalf("newPasswordPolicyFromVariables returned errors: %s", password_policy_errors)
+			}
+
+			password, password_errors := generatePasswordForPolicy(test.generator, password_policy)
+			if password_errors == nil {
+				t.Fatalf("generatePasswordForPolicy returned a password, want errors")
+			}
+
+			if password != "" {
+				t.Errorf("generatePasswordForPolicy returned a password next to its errors")
+			}
+
+			if !strings.Contains(fmt.Sprintf("%s", password_errors), test.want_error) {
+				t.Errorf("got errors %s, want %q", password_errors, test.want_error)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package db_installer
+
+import (
+	"log/slog"
+)
+
+// rotatePassword gives a user a new password on every host with updatePassword and delivers it with writeCredentials.
+// every host and every credential write is journaled as soon as it is done, a failure puts back the old password hash
+// of the hosts already changed and the credentials already written so the old credentials keep logging in
+func rotatePassword(logger *slog.Logger, username string, host_names []string, updatePassword func(host_name string) []error, restorePassword func(host_name string) []error, writeCredentials func(rotation_journal *InstallJournal) []error) []error {
+	rotation_journal := newInstallJournal()
+	rollback := func(errors []error) []error {
+		rollback_errors := rotation_journal.Rollback(logger, nil)
+		if rollback_errors != nil {
+			errors = append(errors, rollback_errors...)
+		}
+		return errors
+	}
+
+	for _, host_name := range host_names {
+		update_password_errors := updatePassword(host_name)
+		if update_password_errors != nil {
+			return rollback(update_password_errors)
+		}
+
+		rotation_journal.Record("update password of "+getAccountName(username, host_name), func() []error {
+			return restorePassword(host_name)
+		})
+	}
+
+	write_errors := writeCredentials(rotation_journal)
+	if write_errors != nil {
+		return rollback(write_errors)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package db_installer
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatePasswordRollsBack(t *testing.T) {
+	tests := []struct {
+		name        string
+		failed_host string
+		failed_sink bool
+		want_error  string
+	}{
+		{"sink failure", "", true, "sink is full"},
+		{"host failure", "10.0.%", false, "host 10.0.% is unreachable"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			directory := t.TempDir()
+			dotenv_sink, dotenv_sink_errors := NewDotenvCredentialSink(directory)
+			if dotenv_sink_errors != nil {
+				t.Fatalf("NewDotenvCredentialSink returned errors: %s", dotenv_sink_errors)
+			}
+
+			failing_sink := *dotenv_sink
+			failing_sink.Write = func(credential DatabaseCredential) []error {
+				return []error{fmt.Errorf("sink is full")}
+			}
+			failing_sink.Snapshot = func(credential DatabaseCredential) (func() []error, []error) {
+				return func() []error { return nil }, nil
+			}
+
+			credential_sinks := []CredentialSink{*dotenv_sink}
+			if test.failed_sink {
+				credential_sinks = append(credential_sinks, failing_sink)
+			}
+
+			getCredential := func(password string) DatabaseCredential {
+				return newDatabaseCredential("holisticw", "127.0.0.1", "3306", "holistic", "holistic_w0", password, nil, "")
+			}
+
+			// the server keeps a password per host, the credential file logs in while it matches the password of every host
+			host_names := []string{"localhost", "10.0.%"}
+			server_passwords := map[string]string{"localhost": "old-password", "10.0.%": "old-password"}
+			write_errors := writeCredentialSinks([]CredentialSink{*dotenv_sink}, []DatabaseCredential{getCredential("old-password")}, nil, nil, slog.New(slog.DiscardHandler))
+			if write_errors != nil {
+				t.Fatalf("writeCredentialSinks returned errors: %s", write_errors)
+			}
+
+			updatePassword := func(host_name string) []error {
+				if host_name == test.failed_host {
+					return []error{fmt.Errorf("host %s is unreachable", host_name)}
+				}
+				server_passwords[host_name] = "new-password"
+				return nil
+			}
+
+			restorePassword := func(host_name string) []error {
+				server_passwords[host_name] = "old-password"
+				return nil
+			}
+
+			rotation_errors := rotatePassword(slog.New(slog.DiscardHandler), "holistic_w0", host_names, updatePassword, restorePassword, func(rotation_journal *InstallJournal) []error {
+				return writeCredentialSinks(credential_sinks, []DatabaseCredential{getCredential("new-password")}, nil, rotation_journal, slog.New(slog.DiscardHandler))
+			})
+			if !strings.Contains(fmt.Sprintf("%s", rotation_errors), test.want_error) {
+				t.Fatalf("got errors %s, want %q", rotation_errors, test.want_error)
+			}
+
+			for _, host_name := range host_names {
+				if server_passwords[host_name] != "old-password" {
+					t.Errorf("host %s has password %s, want the old password back", host_name, server_passwords[host_name])
+				}
+			}
+
+			dotenv, read_error := os.ReadFile(filepath.Join(directory, getCredential("").GetName()+".env"))
+			if read_error != nil {
+				t.Fatalf("credential file is gone: %s", read_error)
+			}
+
+			if !strings.Contains(string(dotenv), ENV_HOLISTIC_DATABASE_PASSWORD()+"=old-password\n") {
+				t.Errorf("credential file does not log in with the old password:\n%s", dotenv)
+			}
+		})
+	}
+}
+
+func TestRotatePassword(t *testing.T) {
+	server_passwords := map[string]string{"localhost": "old-password", "10.0.%": "old-password"}
+	var written []string
+	rotation_errors := rotatePassword(slog.New(slog.DiscardHandler), "holistic_w0", []string{"localhost", "10.0.%"}, func(host_name string) []error {
+		server_passwords[host_name] = "new-password"
+		return nil
+	}, func(host_name string) []error {
+		server_passwords[host_name] = "old-password"
+		return nil
+	}, func(rotation_journal *InstallJournal) []error {
+		written = append(written, "new-password")
+		return nil
+	})
+	if rotation_errors != nil {
+		t.Fatalf("rotatePassword returned errors: %s", rotation_errors)
+	}
+
+	if server_passwords["localhost"] != "new-password" || server_passwords["10.0.%"] != "new-password" || len(written) != 1 {
+		t.Errorf("got passwords %v and %d writes, want the new password everywhere", server_passwords, len(written))
+	}
+}
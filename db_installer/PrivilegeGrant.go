@@ -0,0 +1,186 @@
+package db_installer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// privilegeGrant is a single privilege on a database, a table or a column of a table,
+// SHOW GRANTS lines are split into these so desired and current grants can be compared one by one
+type privilegeGrant struct {
+	database  string
+	table     string
+	privilege string
+	column    string
+}
+
+func newPrivilegeGrant(database string, table string, privilege string, column string) privilegeGrant {
+	if privilege == "ALL" {
+		privilege = "ALL PRIVILEGES"
+	}
+	return privilegeGrant{database: database, table: table, privilege: privilege, column: column}
+}
+
+func getPrivilegeGrantTargetSQL(grant privilegeGrant) string {
+	database := "*"
+	if grant.database != "*" {
+		database = getQuotedIdentifier(grant.database)
+	}
+
+	table := "*"
+	if grant.table != "*" {
+		table = getQuotedIdentifier(grant.table)
+	}
+	return database + "." + table
+}
+
+func getPrivilegeGrantPrivilegeSQL(grant privilegeGrant) string {
+	if grant.column == "" {
+		return grant.privilege
+	}
+	return grant.privilege + " (" + getQuotedIdentifier(grant.column) + ")"
+}
+
+func getPrivilegeGrantDescription(grant privilegeGrant) string {
+	if grant.column == "" {
+		return grant.privilege + " ON " + grant.database + "." + grant.table
+	}
+	return grant.privilege + " (" + grant.column + ") ON " + grant.database + "." + grant.table
+}
+
+func unquoteIdentifier(identifier string) string {
+	if len(identifier) >= 2 && strings.HasPrefix(identifier, "`") && strings.HasSuffix(identifier, "`") {
+		return strings.ReplaceAll(identifier[1:len(identifier)-1], "``", "`")
+	}
+	return identifier
+}
+
+// splitOutsideParentheses splits SELECT (`a`, `b`), INSERT on the commas between privileges only
+func splitOutsideParentheses(value string) []string {
+	var parts []string
+	depth := 0
+	start := 0
+	for index, character := range value {
+		switch character {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(value[start:index]))
+				start = index + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(value[start:]))
+}
+
+func splitGrantTarget(target string) (string, string) {
+	in_quotes := false
+	for index, character := range target {
+		if character == '`' {
+			in_quotes = !in_quotes
+		} else if character == '.' && !in_quotes {
+			return unquoteIdentifier(target[:index]), unquoteIdentifier(target[index+1:])
+		}
+	}
+	return unquoteIdentifier(target), "*"
+}
+
+// splitGrantAccount splits `user`@`host` of a granted role, a role without a host is on %
+func splitGrantAccount(account string) databaseAccount {
+	in_quotes := false
+	for index, character := range account {
+		if character == '`' {
+			in_quotes = !in_quotes
+		} else if character == '@' && !in_quotes {
+			return databaseAccount{username: unquoteIdentifier(account[:index]), host_name: unquoteIdentifier(account[index+1:])}
+		}
+	}
+	return databaseAccount{username: unquoteIdentifier(account), host_name: MYSQL_ROLE_HOST()}
+}
+
+func getGrantedRoleDescription(role databaseAccount) string {
+	return "ROLE " + getAccountName(role.username, role.host_name)
+}
+
+// parseGrantedRoles reads the roles of one line of SHOW GRANTS such as GRANT `r`@`%` TO `u`@`h`,
+// lines granting privileges hold no roles
+func parseGrantedRoles(grant_line string) ([]databaseAccount, []error) {
+	var errors []error
+	var roles []databaseAccount
+	if !strings.HasPrefix(grant_line, "GRANT ") {
+		errors = append(errors, fmt.Errorf("grant: %s is not a GRANT statement", grant_line))
+		return nil, errors
+	}
+
+	to_index := strings.LastIndex(grant_line, " TO ")
+	if to_index == -1 || strings.Contains(grant_line[:to_index], " ON ") {
+		return nil, nil
+	}
+
+	raw_roles := grant_line[len("GRANT "):to_index]
+	in_quotes := false
+	start := 0
+	for index, character := range raw_roles {
+		if character == '`' {
+			in_quotes = !in_quotes
+		} else if character == ',' && !in_quotes {
+			roles = append(roles, splitGrantAccount(strings.TrimSpace(raw_roles[start:index])))
+			start = index + 1
+		}
+	}
+	return append(roles, splitGrantAccount(strings.TrimSpace(raw_roles[start:]))), nil
+}
+
+// parsePrivilegeGrants reads one line of SHOW GRANTS, USAGE and granted roles are not privileges and are skipped
+func parsePrivilegeGrants(grant_line string) ([]privilegeGrant, []error) {
+	var errors []error
+	var grants []privilegeGrant
+	if !strings.HasPrefix(grant_line, "GRANT ") {
+		errors = append(errors, fmt.Errorf("grant: %s is not a GRANT statement", grant_line))
+		return nil, errors
+	}
+
+	on_index := strings.LastIndex(grant_line, " ON ")
+	to_index := strings.LastIndex(grant_line, " TO ")
+	if on_index == -1 || to_index == -1 || to_index < on_index {
+		return nil, nil
+	}
+
+	raw_privileges := grant_line[len("GRANT "):on_index]
+	raw_target := strings.TrimSpace(grant_line[on_index+len(" ON ") : to_index])
+	database, table := splitGrantTarget(raw_target)
+
+	for _, raw_privilege := range splitOutsideParentheses(raw_privileges) {
+		privilege, raw_columns, has_columns := strings.Cut(raw_privilege, "(")
+		privilege = strings.TrimSpace(privilege)
+		if privilege == "USAGE" {
+			continue
+		}
+
+		if !has_columns {
+			grants = append(grants, newPrivilegeGrant(database, table, privilege, ""))
+			continue
+		}
+
+		for _, column := range strings.Split(strings.TrimSuffix(strings.TrimSpace(raw_columns), ")"), ",") {
+			grants = append(grants, newPrivilegeGrant(database, table, privilege, unquoteIdentifier(strings.TrimSpace(column))))
+		}
+	}
+
+	if strings.HasSuffix(grant_line, " WITH GRANT OPTION") {
+		grants = append(grants, newPrivilegeGrant(database, table, "GRANT OPTION", ""))
+	}
+
+	return grants, nil
+}
+
+func getRevokePrivilegeGrantSQL(grant privilegeGrant, username string, host_name string) (string, []error) {
+	account, account_errors := getAccountSQL(username, host_name)
+	if account_errors != nil {
+		return "", account_errors
+	}
+	return "REVOKE " + getPrivilegeGrantPrivilegeSQL(grant) + " ON " + getPrivilegeGrantTargetSQL(grant) + " FROM " + account + ";\n", nil
+}
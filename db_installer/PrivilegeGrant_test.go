@@ -0,0 +1,123 @@
+package db_installer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePrivilegeGrants(t *testing.T) {
+	tests := []struct {
+		name       string
+		grant_line string
+		want       []string
+	}{
+		{"usage", "GRANT USAGE ON *.* TO `holistic_read0`@`%`", nil},
+		{"database", "GRANT SELECT, INSERT, UPDATE ON `holistic`.* TO `holistic_write0`@`10.0.%`", []string{"SELECT ON holistic.*", "INSERT ON holistic.*", "UPDATE ON holistic.*"}},
+		{"all privileges", "GRANT ALL PRIVILEGES ON `holistic`.* TO `holistic_migration`@`localhost`", []string{"ALL PRIVILEGES ON holistic.*"}},
+		{"all", "GRANT ALL ON `holistic`.* TO `holistic_migration`@`localhost`", []string{"ALL PRIVILEGES ON holistic.*"}},
+		{"with grant option", "GRANT SELECT ON `holistic`.`orders` TO `holistic_a`@`app\\_1` WITH GRANT OPTION", []string{"SELECT ON holistic.orders", "GRANT OPTION ON holistic.orders"}},
+		{"global dynamic privileges", "GRANT BACKUP_ADMIN,SYSTEM_USER ON *.* TO `holistic_b`@`localhost`", []string{"BACKUP_ADMIN ON *.*", "SYSTEM_USER ON *.*"}},
+		{"column lists", "GRANT SELECT (`created_at`, `customer_id`), INSERT, UPDATE (`email`) ON `holistic`.`customers` TO `holistic_read0`@`%`", []string{"SELECT (created_at) ON holistic.customers", "SELECT (customer_id) ON holistic.customers", "INSERT ON holistic.customers", "UPDATE (email) ON holistic.customers"}},
+		{"backquoted identifiers", "GRANT SELECT ON `my.db`.`odd``table` TO `u`@`%`", []string{"SELECT ON my.db.odd`table"}},
+		{"backquoted column", "GRANT SELECT (`odd``column`) ON `holistic`.`t` TO `u`@`%`", []string{"SELECT (odd`column) ON holistic.t"}},
+		{"identifiers with keywords", "GRANT SELECT ON `holistic`.`back TO front` TO `u`@`%`", []string{"SELECT ON holistic.back TO front"}},
+		{"underscore and percent hosts", "GRANT SELECT ON `holistic`.* TO `holistic_read0`@`app_%.internal`", []string{"SELECT ON holistic.*"}},
+		{"granted role", "GRANT `holistic_read_role`@`%` TO `holistic_read0`@`%`", nil},
+		{"granted role with admin option", "GRANT `holistic_read_role`@`%` TO `holistic_read0`@`%` WITH ADMIN OPTION", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			grants, grants_errors := parsePrivilegeGrants(test.grant_line)
+			if grants_errors != nil {
+				t.Fatalf("parsePrivilegeGrants returned errors: %s", grants_errors)
+			}
+
+			var descriptions []string
+			for _, grant := range grants {
+				descriptions = append(descriptions, getPrivilegeGrantDescription(grant))
+			}
+
+			if strings.Join(descriptions, "; ") != strings.Join(test.want, "; ") {
+				t.Errorf("got %q, want %q", descriptions, test.want)
+			}
+		})
+	}
+}
+
+func TestParsePrivilegeGrantsErrors(t *testing.T) {
+	for _, grant_line := range []string{"", "REVOKE SELECT ON *.* FROM `u`@`%`", "grant SELECT ON *.* TO `u`@`%`"} {
+		_, grants_errors := parsePrivilegeGrants(grant_line)
+		if grants_errors == nil {
+			t.Errorf("parsePrivilegeGrants(%q) returned no errors", grant_line)
+		}
+	}
+}
+
+func TestParseGrantedRoles(t *testing.T) {
+	tests := []struct {
+		name       string
+		grant_line string
+		want       []string
+	}{
+		{"role", "GRANT `holistic_read_role`@`%` TO `holistic_read0`@`10.0.%`", []string{"ROLE holistic_read_role@%"}},
+		{"roles", "GRANT `holistic_read_role`@`%`,`holistic_write_role`@`%` TO `holistic_write0`@`%`", []string{"ROLE holistic_read_role@%", "ROLE holistic_write_role@%"}},
+		{"roles with spaces", "GRANT `holistic_read_role`@`%`, `reporting`@`localhost` TO `u`@`%`", []string{"ROLE holistic_read_role@%", "ROLE reporting@localhost"}},
+		{"underscore and percent hosts", "GRANT `reporting`@`app_%` TO `u`@`%`", []string{"ROLE reporting@app_%"}},
+		{"role without host", "GRANT `reporting` TO `u`@`%`", []string{"ROLE reporting@%"}},
+		{"with admin option", "GRANT `reporting`@`%` TO `u`@`%` WITH ADMIN OPTION", []string{"ROLE reporting@%"}},
+		{"backquoted identifiers", "GRANT `odd@role`@`%`,`odd,role`@`%`,`odd``role`@`%` TO `u`@`%`", []string{"ROLE odd@role@%", "ROLE odd,role@%", "ROLE odd`role@%"}},
+		{"privileges", "GRANT SELECT ON `holistic`.* TO `u`@`%`", nil},
+		{"usage", "GRANT USAGE ON *.* TO `u`@`%`", nil},
+		{"column privileges", "GRANT SELECT (`id`) ON `holistic`.`t` TO `u`@`%` WITH GRANT OPTION", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			roles, roles_errors := parseGrantedRoles(test.grant_line)
+			if roles_errors != nil {
+				t.Fatalf("parseGrantedRoles returned errors: %s", roles_errors)
+			}
+
+			var descriptions []string
+			for _, role := range roles {
+				descriptions = append(descriptions, getGrantedRoleDescription(role))
+			}
+
+			if strings.Join(descriptions, "; ") != strings.Join(test.want, "; ") {
+				t.Errorf("got %q, want %q", descriptions, test.want)
+			}
+		})
+	}
+}
+
+func TestParseGrantedRolesErrors(t *testing.T) {
+	for _, grant_line := range []string{"", "REVOKE `reporting`@`%` FROM `u`@`%`", "SET DEFAULT ROLE `reporting`@`%` TO `u`@`%`"} {
+		_, roles_errors := parseGrantedRoles(grant_line)
+		if roles_errors == nil {
+			t.Errorf("parseGrantedRoles(%q) returned no errors", grant_line)
+		}
+	}
+}
+
+func TestGetRevokePrivilegeGrantSQL(t *testing.T) {
+	tests := []struct {
+		grant privilegeGrant
+		want  string
+	}{
+		{newPrivilegeGrant("*", "*", "BACKUP_ADMIN", ""), "REVOKE BACKUP_ADMIN ON *.* FROM 'u'@'10.0.%';\n"},
+		{newPrivilegeGrant("holistic", "*", "ALL", ""), "REVOKE ALL PRIVILEGES ON `holistic`.* FROM 'u'@'10.0.%';\n"},
+		{newPrivilegeGrant("holistic", "odd`table", "SELECT", "odd`column"), "REVOKE SELECT (`odd``column`) ON `holistic`.`odd``table` FROM 'u'@'10.0.%';\n"},
+	}
+
+	for _, test := range tests {
+		revoke_sql, revoke_sql_errors := getRevokePrivilegeGrantSQL(test.grant, "u", "10.0.%")
+		if revoke_sql_errors != nil {
+			t.Fatalf("getRevokePrivilegeGrantSQL returned errors: %s", revoke_sql_errors)
+		}
+
+		if revoke_sql != test.want {
+			t.Errorf("got %q, want %q", revoke_sql, test.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package db_installer
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// redactingLogHandler wraps the handler of the logger given to the installer so a password
+// never reaches the log output even when a caller attaches one by mistake
+type redactingLogHandler struct {
+	handler slog.Handler
+}
+
+func newRedactingLogHandler(handler slog.Handler) slog.Handler {
+	return &redactingLogHandler{handler: handler}
+}
+
+func isSecretLogKey(key string) bool {
+	lower_key := strings.ToLower(key)
+	for _, secret_key := range [...]string{"password", "secret", "token"} {
+		if strings.Contains(lower_key, secret_key) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactLogAttr(attr slog.Attr) slog.Attr {
+	if isSecretLogKey(attr.Key) {
+		return slog.String(attr.Key, LOG_REDACTED())
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		group_attrs := attr.Value.Group()
+		redacted_attrs := make([]any, 0, len(group_attrs))
+		for _, group_attr := range group_attrs {
+			redacted_attrs = append(redacted_attrs, redactLogAttr(group_attr))
+		}
+		return slog.Group(attr.Key, redacted_attrs...)
+	}
+
+	return attr
+}
+
+// RedactLogAttr can be used as the ReplaceAttr of a slog.HandlerOptions
+func RedactLogAttr(groups []string, attr slog.Attr) slog.Attr {
+	return redactLogAttr(attr)
+}
+
+func (h *redactingLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *redactingLogHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted_record := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted_record.AddAttrs(redactLogAttr(attr))
+		return true
+	})
+	return h.handler.Handle(ctx, redacted_record)
+}
+
+func (h *redactingLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted_attrs := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		redacted_attrs = append(redacted_attrs, redactLogAttr(attr))
+	}
+	return &redactingLogHandler{handler: h.handler.WithAttrs(redacted_attrs)}
+}
+
+func (h *redactingLogHandler) WithGroup(name string) slog.Handler {
+	return &redactingLogHandler{handler: h.handler.WithGroup(name)}
+}
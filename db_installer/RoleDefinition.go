@@ -0,0 +1,339 @@
+package db_installer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RoleDefinition describes the database users of one role, a pooled role creates pool_size users
+// named username0 to username(pool_size-1) and a role with POOL_SIZE_NONE creates a single user.
+// privileges apply to tables minus the excluded tables, mysql has no deny so excluding tables
+// from * grants the privileges on every other existing table one by one. each user gets one account per
+// allowed host, without allowed hosts the account is created for the database host name.
+// the tls requirement is set on the accounts, the client tls is written into the option files of the role.
+// the authentication plugin defaults to the server default, auth_socket accounts have no password and
+// map to the single host user of the role, mysql_native_password is only meant for legacy drivers.
+// account options limit what each account of the role may use so one role cannot starve another.
+// the privileges are granted once to the mysql role holistic_<name>_role, the accounts of the role hold
+// nothing but that mysql role as their default role
+type RoleDefinition struct {
+	GetName                 func() string
+	GetUsername             func() string
+	GetMySQLRoleName        func() string
+	GetPrivileges           func() []string
+	SetPrivileges           func(privileges []string)
+	GetTables               func() []string
+	SetTables               func(tables []string)
+	GetExcludedTables       func() []string
+	SetExcludedTables       func(excluded_tables []string)
+	GetColumnPrivileges     func() []*ColumnPrivilege
+	AddColumnPrivilege      func(column_privilege *ColumnPrivilege)
+	IsAllTables             func() bool
+	GetPoolSize             func() int
+	SetPoolSize             func(pool_size int)
+	IsPooled                func() bool
+	GetUserCounts           func() []int
+	GetHostUsers            func() []string
+	SetHostUsers            func(host_users []string)
+	GetAllowedHosts         func() []string
+	SetAllowedHosts         func(allowed_hosts []string)
+	GetTLSRequirement       func() *TLSRequirement
+	SetTLSRequirement       func(tls_requirement *TLSRequirement)
+	GetClientTLS            func() *ClientTLS
+	SetClientTLS            func(client_tls *ClientTLS)
+	GetAuthenticationPlugin func() string
+	SetAuthenticationPlugin func(authentication_plugin string)
+	GetSocketUsername       func() string
+	GetAccountOptions       func() *AccountOptions
+	SetAccountOptions       func(account_options *AccountOptions)
+	Validate                func() []error
+}
+
+func getDatabaseLevelPrivileges() []string {
+	return []string{"ALL", "ALTER", "ALTER ROUTINE", "CREATE", "CREATE ROUTINE", "CREATE TEMPORARY TABLES", "CREATE VIEW", "DELETE", "DROP", "EVENT", "EXECUTE", "INDEX", "INSERT", "LOCK TABLES", "REFERENCES", "SELECT", "SHOW VIEW", "TRIGGER", "UPDATE"}
+}
+
+func getTableLevelPrivileges() []string {
+	return []string{"ALL", "ALTER", "CREATE", "CREATE VIEW", "DELETE", "DROP", "INDEX", "INSERT", "REFERENCES", "SELECT", "SHOW VIEW", "TRIGGER", "UPDATE"}
+}
+
+func getAuthenticationPlugins() []string {
+	return []string{AUTHENTICATION_PLUGIN_CACHING_SHA2_PASSWORD(), AUTHENTICATION_PLUGIN_MYSQL_NATIVE_PASSWORD(), AUTHENTICATION_PLUGIN_AUTH_SOCKET()}
+}
+
+func containsString(values []string, value string) bool {
+	for _, current := range values {
+		if current == value {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizePrivileges(privileges []string) []string {
+	var normalized_privileges []string
+	for _, privilege := range privileges {
+		normalized_privileges = append(normalized_privileges, strings.Join(strings.Fields(strings.ToUpper(privilege)), " "))
+	}
+	return normalized_privileges
+}
+
+func NewRoleDefinition(name string, username string, privileges []string, tables []string, pool_size int, host_users []string) *RoleDefinition {
+	role_privileges := normalizePrivileges(privileges)
+	role_tables := tables
+	if len(role_tables) == 0 {
+		role_tables = []string{"*"}
+	}
+	var role_excluded_tables []string
+	var role_column_privileges []*ColumnPrivilege
+	role_pool_size := pool_size
+	role_host_users := host_users
+	var role_allowed_hosts []string
+	var role_tls_requirement *TLSRequirement
+	var role_client_tls *ClientTLS
+	role_authentication_plugin := ""
+	var role_account_options *AccountOptions
+	pooled := pool_size != POOL_SIZE_NONE()
+
+	isPooled := func() bool {
+		return pooled
+	}
+
+	getMySQLRoleName := func() string {
+		return "holistic_" + name + "_role"
+	}
+
+	isAllTables := func() bool {
+		return len(role_tables) == 1 && role_tables[0] == "*"
+	}
+
+	validate := func() []error {
+		var errors []error
+		if len(role_privileges) == 0 && len(role_column_privileges) == 0 {
+			errors = append(errors, fmt.Errorf("role: %s has no privileges", name))
+		}
+
+		allowed_privileges := getDatabaseLevelPrivileges()
+		if !isAllTables() {
+			allowed_privileges = getTableLevelPrivileges()
+		}
+
+		for _, privilege := range role_privileges {
+			if !containsString(allowed_privileges, privilege) {
+				if isAllTables() {
+					errors = append(errors, fmt.Errorf("role: %s privilege: %s is not supported, use one of %s", name, privilege, strings.Join(allowed_privileges, ", ")))
+				} else {
+					errors = append(errors, fmt.Errorf("role: %s privilege: %s is not supported on single tables, use one of %s", name, privilege, strings.Join(allowed_privileges, ", ")))
+				}
+			}
+		}
+
+		for _, table := range role_tables {
+			if table == "*" && !isAllTables() {
+				errors = append(errors, fmt.Errorf("role: %s tables: * cannot be combined with table names", name))
+			}
+		}
+
+		for _, excluded_table := range role_excluded_tables {
+			if excluded_table == "*" {
+				errors = append(errors, fmt.Errorf("role: %s exclude_tables: * is not a table name", name))
+			}
+		}
+
+		if len(role_excluded_tables) > 0 && isAllTables() {
+			for _, privilege := range role_privileges {
+				if !containsString(getTableLevelPrivileges(), privilege) {
+					errors = append(errors, fmt.Errorf("role: %s privilege: %s cannot be combined with exclude_tables, use one of %s", name, privilege, strings.Join(getTableLevelPrivileges(), ", ")))
+				}
+			}
+		}
+
+		for _, column_privilege := range role_column_privileges {
+			column_privilege_errors := column_privilege.Validate()
+			if column_privilege_errors != nil {
+				for _, column_privilege_error := range column_privilege_errors {
+					errors = append(errors, fmt.Errorf("role: %s %s", name, column_privilege_error))
+				}
+			}
+		}
+
+		for index, allowed_host := range role_allowed_hosts {
+			if containsString(role_allowed_hosts[:index], allowed_host) {
+				errors = append(errors, fmt.Errorf("role: %s allowed host: %s is listed more than once", name, allowed_host))
+			}
+
+			allowed_host_errors := validateHostPattern(allowed_host)
+			if allowed_host_errors != nil {
+				for _, allowed_host_error := range allowed_host_errors {
+					errors = append(errors, fmt.Errorf("role: %s %s", name, allowed_host_error))
+				}
+			}
+		}
+
+		if role_tls_requirement != nil {
+			tls_requirement_errors := role_tls_requirement.Validate()
+			if tls_requirement_errors != nil {
+				for _, tls_requirement_error := range tls_requirement_errors {
+					errors = append(errors, fmt.Errorf("role: %s %s", name, tls_requirement_error))
+				}
+			}
+		}
+
+		if role_client_tls != nil {
+			client_tls_errors := role_client_tls.Validate()
+			if client_tls_errors != nil {
+				for _, client_tls_error := range client_tls_errors {
+					errors = append(errors, fmt.Errorf("role: %s %s", name, client_tls_error))
+				}
+			}
+		}
+
+		if role_authentication_plugin != "" && !containsString(getAuthenticationPlugins(), role_authentication_plugin) {
+			errors = append(errors, fmt.Errorf("role: %s authentication plugin: %s is not supported, use one of %s", name, role_authentication_plugin, strings.Join(getAuthenticationPlugins(), ", ")))
+		}
+
+		if role_authentication_plugin == AUTHENTICATION_PLUGIN_AUTH_SOCKET() {
+			if len(role_host_users) != 1 {
+				errors = append(errors, fmt.Errorf("role: %s authentication plugin: %s maps the accounts to one host user, the role has %d", name, role_authentication_plugin, len(role_host_users)))
+			}
+
+			if len(role_allowed_hosts) != 1 || role_allowed_hosts[0] != "localhost" {
+				errors = append(errors, fmt.Errorf("role: %s authentication plugin: %s only works over the local socket, set allowed_hosts to localhost", name, role_authentication_plugin))
+			}
+		}
+
+		if role_account_options != nil {
+			account_options_errors := role_account_options.Validate()
+			if account_options_errors != nil {
+				for _, account_options_error := range account_options_errors {
+					errors = append(errors, fmt.Errorf("role: %s %s", name, account_options_error))
+				}
+			}
+		}
+
+		if len(getMySQLRoleName()) > MYSQL_USERNAME_LENGTH_MAXIMUM() {
+			errors = append(errors, fmt.Errorf("role: %s mysql role name %s is longer than %d characters, use a shorter role name", name, getMySQLRoleName(), MYSQL_USERNAME_LENGTH_MAXIMUM()))
+		}
+
+		if username == getMySQLRoleName() {
+			errors = append(errors, fmt.Errorf("role: %s database username %s is the name of its mysql role", name, username))
+		}
+
+		if isPooled() && (role_pool_size < POOL_SIZE_MINIMUM() || role_pool_size > POOL_SIZE_MAXIMUM()) {
+			errors = append(errors, fmt.Errorf("%s pool size: %d must be between %d and %d", name, role_pool_size, POOL_SIZE_MINIMUM(), POOL_SIZE_MAXIMUM()))
+		}
+
+		if len(errors) > 0 {
+			return errors
+		}
+
+		return nil
+	}
+
+	return &RoleDefinition{
+		GetName: func() string {
+			return name
+		},
+		GetUsername: func() string {
+			return username
+		},
+		GetMySQLRoleName: func() string {
+			return getMySQLRoleName()
+		},
+		GetPrivileges: func() []string {
+			return role_privileges
+		},
+		SetPrivileges: func(value []string) {
+			role_privileges = normalizePrivileges(value)
+		},
+		GetTables: func() []string {
+			return role_tables
+		},
+		SetTables: func(value []string) {
+			role_tables = value
+			if len(role_tables) == 0 {
+				role_tables = []string{"*"}
+			}
+		},
+		GetPoolSize: func() int {
+			return role_pool_size
+		},
+		SetPoolSize: func(value int) {
+			role_pool_size = value
+		},
+		GetExcludedTables: func() []string {
+			return role_excluded_tables
+		},
+		SetExcludedTables: func(value []string) {
+			role_excluded_tables = value
+		},
+		GetColumnPrivileges: func() []*ColumnPrivilege {
+			return role_column_privileges
+		},
+		AddColumnPrivilege: func(value *ColumnPrivilege) {
+			role_column_privileges = append(role_column_privileges, value)
+		},
+		IsAllTables: func() bool {
+			return isAllTables()
+		},
+		IsPooled: func() bool {
+			return isPooled()
+		},
+		GetUserCounts: func() []int {
+			if !isPooled() {
+				return []int{-1}
+			}
+
+			var user_counts []int
+			for user_count := 0; user_count < role_pool_size; user_count++ {
+				user_counts = append(user_counts, user_count)
+			}
+			return user_counts
+		},
+		GetHostUsers: func() []string {
+			return role_host_users
+		},
+		SetHostUsers: func(value []string) {
+			role_host_users = value
+		},
+		GetAllowedHosts: func() []string {
+			return role_allowed_hosts
+		},
+		SetAllowedHosts: func(value []string) {
+			role_allowed_hosts = normalizeHostPatterns(value)
+		},
+		GetTLSRequirement: func() *TLSRequirement {
+			return role_tls_requirement
+		},
+		SetTLSRequirement: func(value *TLSRequirement) {
+			role_tls_requirement = value
+		},
+		GetClientTLS: func() *ClientTLS {
+			return role_client_tls
+		},
+		GetAuthenticationPlugin: func() string {
+			return role_authentication_plugin
+		},
+		SetAuthenticationPlugin: func(value string) {
+			role_authentication_plugin = strings.ToLower(strings.TrimSpace(value))
+		},
+		GetAccountOptions: func() *AccountOptions {
+			return role_account_options
+		},
+		SetAccountOptions: func(value *AccountOptions) {
+			role_account_options = value
+		},
+		GetSocketUsername: func() string {
+			if len(role_host_users) == 0 {
+				return ""
+			}
+			return role_host_users[0]
+		},
+		SetClientTLS: func(value *ClientTLS) {
+			role_client_tls = value
+		},
+		Validate: func() []error {
+			return validate()
+		},
+	}
+}
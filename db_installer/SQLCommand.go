@@ -0,0 +1,118 @@
+package db_installer
+
+import (
+	"fmt"
+	"strings"
+
+	host_client "github.com/matehaxor03/holistic_host_client/host_client"
+	json "github.com/matehaxor03/holistic_json/json"
+)
+
+type SQLCommand struct {
+	ExecuteUnsafeCommand func(raw_sql strings.Builder, options json.Map) (json.Array, []error)
+}
+
+// newSQLCommand runs statements the db client has no support for (DROP USER, REVOKE, SHOW GRANTS ...)
+// using the root credentials file that install writes for the host user running the installer.
+// client_tls may be nil, otherwise the connection uses it whatever the option file says
+func newSQLCommand(host_client_user host_client.User, host_name string, port_number string, database_username string, client_tls *ClientTLS) (*SQLCommand, []error) {
+	var errors []error
+
+	if host_name == "" {
+		errors = append(errors, fmt.Errorf("error: SQLCommand host_name is empty string"))
+	}
+
+	if port_number == "" {
+		errors = append(errors, fmt.Errorf("error: SQLCommand port_number is empty string"))
+	}
+
+	if database_username == "" {
+		errors = append(errors, fmt.Errorf("error: SQLCommand database_username is empty string"))
+	}
+
+	if len(errors) > 0 {
+		return nil, errors
+	}
+
+	getOptionFile := func() (string, []error) {
+		var errors []error
+		db_directory, db_directory_errors := host_client_user.GetDirectoryDBAbsoluteDirectory()
+		if db_directory_errors != nil {
+			return "", db_directory_errors
+		} else if db_directory == nil {
+			errors = append(errors, fmt.Errorf("%s has no db directory", host_client_user.GetUsername()))
+			return "", errors
+		}
+		return db_directory.GetPathAsString() + "/holistic_db_config#" + host_name + "#" + port_number + "##" + database_username + ".config", nil
+	}
+
+	return newSQLCommandUsingOptionFile(host_client_user, host_name, port_number, client_tls, getOptionFile), nil
+}
+
+// newSQLCommandUsingOptionFile runs statements as host_client_user with the credentials of any option file,
+// status uses it to check that the option files written for the host users can log in. client_tls may be nil
+func newSQLCommandUsingOptionFile(host_client_user host_client.User, host_name string, port_number string, client_tls *ClientTLS, getOptionFile func() (string, []error)) *SQLCommand {
+	executeUnsafeCommand := func(raw_sql strings.Builder, options json.Map) (json.Array, []error) {
+		var errors []error
+		records := json.NewArrayValue()
+
+		option_file, option_file_errors := getOptionFile()
+		if option_file_errors != nil {
+			return records, option_file_errors
+		}
+
+		credentials_command := "--defaults-extra-file=" + option_file
+		host_command := fmt.Sprintf("--host=%s --port=%s --protocol=TCP", host_name, port_number)
+		if client_tls != nil {
+			host_command += " " + client_tls.GetCommandOptions()
+		}
+		sql_header_command := fmt.Sprintf("/usr/local/mysql/bin/mysql %s %s --batch --wait --quick ", credentials_command, host_command)
+
+		var sql_command strings.Builder
+		if options.IsBoolTrue("use_mysql_database") {
+			sql_command.WriteString("USE mysql;\n")
+		}
+		sql_command.WriteString(raw_sql.String())
+
+		stdout_lines, bash_errors := host_client_user.ExecuteUnsafeCommandUsingFiles(sql_header_command, sql_command.String())
+		if bash_errors != nil {
+			errors = append(errors, bash_errors...)
+			return records, errors
+		}
+
+		if options.IsBoolTrue("read_no_records") {
+			return records, nil
+		}
+
+		var columns []string
+		for _, shell_row := range stdout_lines {
+			if strings.TrimSpace(shell_row) == "" {
+				continue
+			}
+
+			values := strings.Split(strings.TrimRight(shell_row, "\r\n"), "\t")
+			if columns == nil {
+				columns = values
+				continue
+			}
+
+			record := json.NewMap()
+			for index, column := range columns {
+				if index < len(values) {
+					record.SetStringValue(column, values[index])
+				} else {
+					record.SetNil(column)
+				}
+			}
+			records.AppendMap(record)
+		}
+
+		return records, nil
+	}
+
+	return &SQLCommand{
+		ExecuteUnsafeCommand: func(raw_sql strings.Builder, options json.Map) (json.Array, []error) {
+			return executeUnsafeCommand(raw_sql, options)
+		},
+	}
+}
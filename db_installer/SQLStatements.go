@@ -0,0 +1,505 @@
+package db_installer
+
+import (
+	"fmt"
+	"strings"
+
+	common "github.com/matehaxor03/holistic_common/common"
+)
+
+func getQuotedString(value string) (string, []error) {
+	var errors []error
+	value_escaped, value_escaped_error := common.EscapeString(value, "'")
+	if value_escaped_error != nil {
+		errors = append(errors, value_escaped_error)
+		return "", errors
+	}
+	return "'" + value_escaped + "'", nil
+}
+
+func getAccountSQL(username string, host_name string) (string, []error) {
+	var errors []error
+	username_quoted, username_quoted_errors := getQuotedString(username)
+	if username_quoted_errors != nil {
+		errors = append(errors, username_quoted_errors...)
+	}
+
+	host_name_quoted, host_name_quoted_errors := getQuotedString(host_name)
+	if host_name_quoted_errors != nil {
+		errors = append(errors, host_name_quoted_errors...)
+	}
+
+	if len(errors) > 0 {
+		return "", errors
+	}
+
+	return fmt.Sprintf("%s@%s", username_quoted, host_name_quoted), nil
+}
+
+// getAccountsSQL lists the accounts of a statement that changes several accounts at once
+func getAccountsSQL(accounts []databaseAccount) (string, []error) {
+	var errors []error
+	var accounts_sql []string
+	for _, account := range accounts {
+		account_sql, account_sql_errors := getAccountSQL(account.username, account.host_name)
+		if account_sql_errors != nil {
+			errors = append(errors, account_sql_errors...)
+			continue
+		}
+		accounts_sql = append(accounts_sql, account_sql)
+	}
+
+	if len(accounts) == 0 {
+		errors = append(errors, fmt.Errorf("error: accounts is empty"))
+	}
+
+	if len(errors) > 0 {
+		return "", errors
+	}
+
+	return strings.Join(accounts_sql, ", "), nil
+}
+
+func getDropUsersSQL(accounts []databaseAccount) (string, []error) {
+	accounts_sql, accounts_sql_errors := getAccountsSQL(accounts)
+	if accounts_sql_errors != nil {
+		return "", accounts_sql_errors
+	}
+	return "DROP USER IF EXISTS " + accounts_sql + ";\n", nil
+}
+
+func getDropUserSQL(username string, host_name string) (string, []error) {
+	account, account_errors := getAccountSQL(username, host_name)
+	if account_errors != nil {
+		return "", account_errors
+	}
+	return "DROP USER IF EXISTS " + account + ";\n", nil
+}
+
+func getCreateRoleSQL(role_name string) (string, []error) {
+	account, account_errors := getAccountSQL(role_name, MYSQL_ROLE_HOST())
+	if account_errors != nil {
+		return "", account_errors
+	}
+	return "CREATE ROLE IF NOT EXISTS " + account + ";\n", nil
+}
+
+func getDropRoleSQL(role_name string) (string, []error) {
+	account, account_errors := getAccountSQL(role_name, MYSQL_ROLE_HOST())
+	if account_errors != nil {
+		return "", account_errors
+	}
+	return "DROP ROLE IF EXISTS " + account + ";\n", nil
+}
+
+// getGrantRoleSQL grants a mysql role to several accounts and makes it their default role,
+// a granted role that is not a default role is only active after SET ROLE
+func getGrantRoleSQL(role_name string, accounts []databaseAccount) (string, []error) {
+	role_account, role_account_errors := getAccountSQL(role_name, MYSQL_ROLE_HOST())
+	if role_account_errors != nil {
+		return "", role_account_errors
+	}
+
+	accounts_sql, accounts_sql_errors := getAccountsSQL(accounts)
+	if accounts_sql_errors != nil {
+		return "", accounts_sql_errors
+	}
+	return "GRANT " + role_account + " TO " + accounts_sql + ";\nSET DEFAULT ROLE " + role_account + " TO " + accounts_sql + ";\n", nil
+}
+
+// getRegrantRoleSQL puts back a mysql role revoked from an account, the role keeps its own host
+func getRegrantRoleSQL(role databaseAccount, username string, host_name string) (string, []error) {
+	role_account, role_account_errors := getAccountSQL(role.username, role.host_name)
+	if role_account_errors != nil {
+		return "", role_account_errors
+	}
+
+	account, account_errors := getAccountSQL(username, host_name)
+	if account_errors != nil {
+		return "", account_errors
+	}
+	return "GRANT " + role_account + " TO " + account + ";\n", nil
+}
+
+func getRevokeRoleSQL(role databaseAccount, username string, host_name string) (string, []error) {
+	role_account, role_account_errors := getAccountSQL(role.username, role.host_name)
+	if role_account_errors != nil {
+		return "", role_account_errors
+	}
+
+	account, account_errors := getAccountSQL(username, host_name)
+	if account_errors != nil {
+		return "", account_errors
+	}
+	return "REVOKE " + role_account + " FROM " + account + ";\n", nil
+}
+
+func getSelectUsersByPrefixSQL(username_prefix string) (string, []error) {
+	prefix_escaped, prefix_escaped_error := common.EscapeString(username_prefix, "'")
+	if prefix_escaped_error != nil {
+		return "", []error{prefix_escaped_error}
+	}
+	prefix_escaped = "'" + strings.NewReplacer("_", "\\_", "%", "\\%").Replace(prefix_escaped) + "%'"
+	return "SELECT User, Host FROM mysql.user WHERE User LIKE " + prefix_escaped + " ORDER BY User;\n", nil
+}
+
+func getRevokeAllSQL(username string, host_name string) (string, []error) {
+	account, account_errors := getAccountSQL(username, host_name)
+	if account_errors != nil {
+		return "", account_errors
+	}
+	return "REVOKE ALL PRIVILEGES, GRANT OPTION FROM " + account + ";\n", nil
+}
+
+func getRetainCurrentPasswordSQL(username string, host_name string, new_password string) (string, []error) {
+	account, account_errors := getAccountSQL(username, host_name)
+	if account_errors != nil {
+		return "", account_errors
+	}
+
+	new_password_quoted, new_password_quoted_errors := getQuotedString(new_password)
+	if new_password_quoted_errors != nil {
+		return "", new_password_quoted_errors
+	}
+	return "ALTER USER " + account + " IDENTIFIED BY " + new_password_quoted + " RETAIN CURRENT PASSWORD;\n", nil
+}
+
+func getDiscardOldPasswordSQL(username string, host_name string) (string, []error) {
+	account, account_errors := getAccountSQL(username, host_name)
+	if account_errors != nil {
+		return "", account_errors
+	}
+	return "ALTER USER " + account + " DISCARD OLD PASSWORD;\n", nil
+}
+
+func getSetGlobalSQL(name string, value string) (string, []error) {
+	return getSetVariableSQL("GLOBAL", name, value)
+}
+
+func getSetSessionSQL(name string, value string) (string, []error) {
+	return getSetVariableSQL("SESSION", name, value)
+}
+
+// isSQLNumber is true for integers and decimals, numeric variables refuse a quoted value such as long_query_time = '10.000000'
+func isSQLNumber(value string) bool {
+	isDigits := func(part string) bool {
+		return part != "" && strings.Trim(part, "0123456789") == ""
+	}
+
+	integer, fraction, has_fraction := strings.Cut(strings.TrimPrefix(value, "-"), ".")
+	return isDigits(integer) && (!has_fraction || isDigits(fraction))
+}
+
+func getSetVariableSQL(scope string, name string, value string) (string, []error) {
+	var errors []error
+	if name == "" || strings.Trim(name, "abcdefghijklmnopqrstuvwxyz_") != "" {
+		errors = append(errors, fmt.Errorf("global setting: %s must contain only lowercase letters and underscores", name))
+		return "", errors
+	}
+
+	if isSQLNumber(value) {
+		return "SET " + scope + " " + name + " = " + value + ";\n", nil
+	}
+
+	value_quoted, value_quoted_errors := getQuotedString(value)
+	if value_quoted_errors != nil {
+		return "", value_quoted_errors
+	}
+	return "SET " + scope + " " + name + " = " + value_quoted + ";\n", nil
+}
+
+func getQuotedIdentifier(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+// getGrantSQL grants privileges on every table of the database when table_name is *
+func getGrantSQL(privileges []string, database_name string, table_name string, accounts []databaseAccount) (string, []error) {
+	accounts_sql, accounts_sql_errors := getAccountsSQL(accounts)
+	if accounts_sql_errors != nil {
+		return "", accounts_sql_errors
+	}
+
+	table := "*"
+	if table_name != "*" {
+		table = getQuotedIdentifier(table_name)
+	}
+	return "GRANT " + strings.Join(privileges, ", ") + " ON " + getQuotedIdentifier(database_name) + "." + table + " TO " + accounts_sql + ";\n", nil
+}
+
+func getSelectDatabaseSQL(database_name string) (string, []error) {
+	database_name_quoted, database_name_quoted_errors := getQuotedString(database_name)
+	if database_name_quoted_errors != nil {
+		return "", database_name_quoted_errors
+	}
+	return "SELECT DEFAULT_CHARACTER_SET_NAME, DEFAULT_COLLATION_NAME FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = " + database_name_quoted + ";\n", nil
+}
+
+func getShowGlobalVariableSQL(name string) (string, []error) {
+	name_quoted, name_quoted_errors := getQuotedString(name)
+	if name_quoted_errors != nil {
+		return "", name_quoted_errors
+	}
+	return "SHOW GLOBAL VARIABLES LIKE " + name_quoted + ";\n", nil
+}
+
+// getCreateDatabaseSQL expects a character set and collate checked by the validator, they cannot be quoted
+func getCreateDatabaseSQL(database_name string, character_set string, collate string) string {
+	return "CREATE DATABASE IF NOT EXISTS " + getQuotedIdentifier(database_name) + " CHARACTER SET " + character_set + " COLLATE " + collate + ";\n"
+}
+
+// getCreateDatabaseMigrationTableSQL is the table the db client creates for install, together with the
+// columns the db client adds to every table. the zero default of archieved_date needs an sql_mode without NO_ZERO_DATE
+func getCreateDatabaseMigrationTableSQL(database_name string) string {
+	return "CREATE TABLE IF NOT EXISTS " + getQuotedIdentifier(database_name) + ".`DatabaseMigration` (" +
+		"`database_migration_id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY, " +
+		"`current` BIGINT NOT NULL DEFAULT -1, " +
+		"`desired` BIGINT NOT NULL DEFAULT 0, " +
+		"`enabled` BOOLEAN NOT NULL DEFAULT 1, " +
+		"`archieved` BOOLEAN NOT NULL DEFAULT 0, " +
+		"`created_date` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6), " +
+		"`last_modified_date` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6), " +
+		"`archieved_date` TIMESTAMP(6) NOT NULL DEFAULT '0000-00-00 00:00:00.000000');\n"
+}
+
+// getInsertDatabaseMigrationSQL seeds the default record unless the table already holds one
+func getInsertDatabaseMigrationSQL(database_name string) string {
+	table := getQuotedIdentifier(database_name) + ".`DatabaseMigration`"
+	return "INSERT INTO " + table + " (`current`, `desired`) SELECT -1, 0 FROM DUAL WHERE NOT EXISTS (SELECT 1 FROM " + table + ");\n"
+}
+
+func getSelectDatabaseMigrationSQL(database_name string) string {
+	return "SELECT `current`, `desired` FROM " + getQuotedIdentifier(database_name) + ".`DatabaseMigration` ORDER BY `database_migration_id` LIMIT 1;\n"
+}
+
+func getSelectTablesSQL(database_name string) (string, []error) {
+	database_name_quoted, database_name_quoted_errors := getQuotedString(database_name)
+	if database_name_quoted_errors != nil {
+		return "", database_name_quoted_errors
+	}
+	return "SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = " + database_name_quoted + " AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME;\n", nil
+}
+
+// getRevokeDatabaseLevelSQL needs mysql 8.0.30 or later for REVOKE IF EXISTS
+func getRevokeDatabaseLevelSQL(privileges []string, database_name string, accounts []databaseAccount) (string, []error) {
+	accounts_sql, accounts_sql_errors := getAccountsSQL(accounts)
+	if accounts_sql_errors != nil {
+		return "", accounts_sql_errors
+	}
+	return "REVOKE IF EXISTS " + strings.Join(privileges, ", ") + " ON " + getQuotedIdentifier(database_name) + ".* FROM " + accounts_sql + ";\n", nil
+}
+
+func getColumnGrantSQL(privileges []string, columns []string, database_name string, table_name string, accounts []databaseAccount) (string, []error) {
+	accounts_sql, accounts_sql_errors := getAccountsSQL(accounts)
+	if accounts_sql_errors != nil {
+		return "", accounts_sql_errors
+	}
+
+	var quoted_columns []string
+	for _, column := range columns {
+		quoted_columns = append(quoted_columns, getQuotedIdentifier(column))
+	}
+
+	var grant_privileges []string
+	for _, privilege := range privileges {
+		grant_privileges = append(grant_privileges, privilege+" ("+strings.Join(quoted_columns, ", ")+")")
+	}
+	return "GRANT " + strings.Join(grant_privileges, ", ") + " ON " + getQuotedIdentifier(database_name) + "." + getQuotedIdentifier(table_name) + " TO " + accounts_sql + ";\n", nil
+}
+
+func getShowGrantsSQL(username string, host_name string) (string, []error) {
+	account, account_errors := getAccountSQL(username, host_name)
+	if account_errors != nil {
+		return "", account_errors
+	}
+	return "SHOW GRANTS FOR " + account + ";\n", nil
+}
+
+func getGrantPrivilegeGrantSQL(grant privilegeGrant, username string, host_name string) (string, []error) {
+	account, account_errors := getAccountSQL(username, host_name)
+	if account_errors != nil {
+		return "", account_errors
+	}
+	return "GRANT " + getPrivilegeGrantPrivilegeSQL(grant) + " ON " + getPrivilegeGrantTargetSQL(grant) + " TO " + account + ";\n", nil
+}
+
+// getAuthenticationSQL is the IDENTIFIED clause of an account, auth_socket maps the account to socket_username
+// instead of a password and an empty authentication_plugin leaves the choice to the server default
+func getAuthenticationSQL(authentication_plugin string, password string, socket_username string) (string, []error) {
+	if authentication_plugin == AUTHENTICATION_PLUGIN_AUTH_SOCKET() {
+		socket_username_quoted, socket_username_quoted_errors := getQuotedString(socket_username)
+		if socket_username_quoted_errors != nil {
+			return "", socket_username_quoted_errors
+		}
+		return "IDENTIFIED WITH " + authentication_plugin + " AS " + socket_username_quoted, nil
+	}
+
+	password_quoted, password_quoted_errors := getQuotedString(password)
+	if password_quoted_errors != nil {
+		return "", password_quoted_errors
+	}
+
+	if authentication_plugin == "" {
+		return "IDENTIFIED BY " + password_quoted, nil
+	}
+	return "IDENTIFIED WITH " + authentication_plugin + " BY " + password_quoted, nil
+}
+
+// getUsersAuthenticationSQL pairs every account with the IDENTIFIED clause of its own password
+func getUsersAuthenticationSQL(accounts []databaseAccount, authentication_plugin string, socket_username string) (string, []error) {
+	var errors []error
+	var users_sql []string
+	for _, account := range accounts {
+		account_sql, account_sql_errors := getAccountSQL(account.username, account.host_name)
+		if account_sql_errors != nil {
+			errors = append(errors, account_sql_errors...)
+			continue
+		}
+
+		authentication_sql, authentication_sql_errors := getAuthenticationSQL(authentication_plugin, account.password, socket_username)
+		if authentication_sql_errors != nil {
+			errors = append(errors, authentication_sql_errors...)
+			continue
+		}
+		users_sql = append(users_sql, account_sql+" "+authentication_sql)
+	}
+
+	if len(accounts) == 0 {
+		errors = append(errors, fmt.Errorf("error: accounts is empty"))
+	}
+
+	if len(errors) > 0 {
+		return "", errors
+	}
+
+	return strings.Join(users_sql, ", "), nil
+}
+
+func getCreateUsersSQL(accounts []databaseAccount, authentication_plugin string, socket_username string) (string, []error) {
+	users_sql, users_sql_errors := getUsersAuthenticationSQL(accounts, authentication_plugin, socket_username)
+	if users_sql_errors != nil {
+		return "", users_sql_errors
+	}
+	return "CREATE USER IF NOT EXISTS " + users_sql + ";\n", nil
+}
+
+func getAlterUsersAuthenticationSQL(accounts []databaseAccount, authentication_plugin string, socket_username string) (string, []error) {
+	users_sql, users_sql_errors := getUsersAuthenticationSQL(accounts, authentication_plugin, socket_username)
+	if users_sql_errors != nil {
+		return "", users_sql_errors
+	}
+	return "ALTER USER " + users_sql + ";\n", nil
+}
+
+func getUpdatePasswordSQL(username string, host_name string, password string) (string, []error) {
+	account, account_errors := getAccountSQL(username, host_name)
+	if account_errors != nil {
+		return "", account_errors
+	}
+
+	password_quoted, password_quoted_errors := getQuotedString(password)
+	if password_quoted_errors != nil {
+		return "", password_quoted_errors
+	}
+	return "ALTER USER " + account + " IDENTIFIED BY " + password_quoted + ";\n", nil
+}
+
+// getSelectPasswordPolicySQL finds the validate_password.* variables of the component and the validate_password_* of the older plugin
+func getSelectPasswordPolicySQL() string {
+	return "SHOW GLOBAL VARIABLES LIKE 'validate_password%';\n"
+}
+
+// getSelectUsersAuthenticationSQL reads the accounts of several users with their password hashes as hex,
+// a failed install puts a hash back with getRestoreUserAuthenticationSQL
+func getSelectUsersAuthenticationSQL(usernames []string) (string, []error) {
+	var errors []error
+	var usernames_quoted []string
+	for _, username := range usernames {
+		username_quoted, username_quoted_errors := getQuotedString(username)
+		if username_quoted_errors != nil {
+			errors = append(errors, username_quoted_errors...)
+			continue
+		}
+		usernames_quoted = append(usernames_quoted, username_quoted)
+	}
+
+	if len(usernames) == 0 {
+		errors = append(errors, fmt.Errorf("error: usernames is empty"))
+	}
+
+	if len(errors) > 0 {
+		return "", errors
+	}
+	return "SELECT User, Host, plugin, HEX(authentication_string) AS authentication_string FROM mysql.user WHERE User IN (" + strings.Join(usernames_quoted, ", ") + ") ORDER BY User, Host;\n", nil
+}
+
+// getRestoreUserAuthenticationSQL needs mysql 8.0.17 or later for hex authentication strings
+func getRestoreUserAuthenticationSQL(username string, host_name string, authentication_plugin string, authentication_string_hex string) (string, []error) {
+	var errors []error
+	account, account_errors := getAccountSQL(username, host_name)
+	if account_errors != nil {
+		return "", account_errors
+	}
+
+	if authentication_plugin == "" || strings.Trim(authentication_plugin, "abcdefghijklmnopqrstuvwxyz0123456789_") != "" {
+		errors = append(errors, fmt.Errorf("authentication plugin: %s is not a plugin name", authentication_plugin))
+	}
+
+	if strings.Trim(authentication_string_hex, "0123456789ABCDEFabcdef") != "" || len(authentication_string_hex)%2 != 0 {
+		errors = append(errors, fmt.Errorf("authentication string of %s is not hex", getAccountName(username, host_name)))
+	}
+
+	if len(errors) > 0 {
+		return "", errors
+	}
+
+	if authentication_string_hex == "" {
+		return "ALTER USER " + account + " IDENTIFIED WITH " + authentication_plugin + " AS '';\n", nil
+	}
+	return "ALTER USER " + account + " IDENTIFIED WITH " + authentication_plugin + " AS 0x" + authentication_string_hex + ";\n", nil
+}
+
+func getSelectUserHostsSQL(username string) (string, []error) {
+	username_quoted, username_quoted_errors := getQuotedString(username)
+	if username_quoted_errors != nil {
+		return "", username_quoted_errors
+	}
+	return "SELECT User, Host FROM mysql.user WHERE User = " + username_quoted + " ORDER BY Host;\n", nil
+}
+
+// getRequireTLSSQL sets the REQUIRE clause of accounts, an issuer or subject replaces X509 as they imply it
+func getRequireTLSSQL(accounts []databaseAccount, tls_requirement *TLSRequirement) (string, []error) {
+	accounts_sql, accounts_sql_errors := getAccountsSQL(accounts)
+	if accounts_sql_errors != nil {
+		return "", accounts_sql_errors
+	}
+
+	if tls_requirement.GetIssuer() == "" && tls_requirement.GetSubject() == "" {
+		return "ALTER USER " + accounts_sql + " REQUIRE " + tls_requirement.GetRequire() + ";\n", nil
+	}
+
+	var requirements []string
+	if tls_requirement.GetIssuer() != "" {
+		issuer_quoted, issuer_quoted_errors := getQuotedString(tls_requirement.GetIssuer())
+		if issuer_quoted_errors != nil {
+			return "", issuer_quoted_errors
+		}
+		requirements = append(requirements, "ISSUER "+issuer_quoted)
+	}
+
+	if tls_requirement.GetSubject() != "" {
+		subject_quoted, subject_quoted_errors := getQuotedString(tls_requirement.GetSubject())
+		if subject_quoted_errors != nil {
+			return "", subject_quoted_errors
+		}
+		requirements = append(requirements, "SUBJECT "+subject_quoted)
+	}
+	return "ALTER USER " + accounts_sql + " REQUIRE " + strings.Join(requirements, " AND ") + ";\n", nil
+}
+
+func getAlterUserAccountOptionsSQL(accounts []databaseAccount, account_options *AccountOptions) (string, []error) {
+	accounts_sql, accounts_sql_errors := getAccountsSQL(accounts)
+	if accounts_sql_errors != nil {
+		return "", accounts_sql_errors
+	}
+	return "ALTER USER " + accounts_sql + " " + account_options.GetSQL() + ";\n", nil
+}
@@ -0,0 +1,39 @@
+package db_installer
+
+import (
+	"testing"
+)
+
+func TestGetSetGlobalSQL(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"max_connections", "151", "SET GLOBAL max_connections = 151;\n"},
+		{"long_query_time", "10.000000", "SET GLOBAL long_query_time = 10.000000;\n"},
+		{"auto_increment_offset", "-1", "SET GLOBAL auto_increment_offset = -1;\n"},
+		{"time_zone", "-05:00", "SET GLOBAL time_zone = '-05:00';\n"},
+		{"general_log", "OFF", "SET GLOBAL general_log = 'OFF';\n"},
+		{"sql_mode", "STRICT_TRANS_TABLES,NO_ENGINE_SUBSTITUTION", "SET GLOBAL sql_mode = 'STRICT_TRANS_TABLES,NO_ENGINE_SUBSTITUTION';\n"},
+		{"sql_mode", "", "SET GLOBAL sql_mode = '';\n"},
+		{"version_comment", "1.", "SET GLOBAL version_comment = '1.';\n"},
+		{"version_comment", ".5", "SET GLOBAL version_comment = '.5';\n"},
+		{"version_comment", "1.2.3", "SET GLOBAL version_comment = '1.2.3';\n"},
+	}
+
+	for _, test := range tests {
+		set_global_sql, set_global_sql_errors := getSetGlobalSQL(test.name, test.value)
+		if set_global_sql_errors != nil {
+			t.Fatalf("getSetGlobalSQL(%q, %q) returned errors: %s", test.name, test.value, set_global_sql_errors)
+		}
+
+		if set_global_sql != test.want {
+			t.Errorf("got %q, want %q", set_global_sql, test.want)
+		}
+	}
+
+	if _, set_global_sql_errors := getSetGlobalSQL("max_connections; DROP USER root", "1"); set_global_sql_errors == nil {
+		t.Errorf("getSetGlobalSQL accepted a name that is not a variable name")
+	}
+}
@@ -0,0 +1,175 @@
+package db_installer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	host_client "github.com/matehaxor03/holistic_host_client/host_client"
+	json "github.com/matehaxor03/holistic_json/json"
+)
+
+// SecretStoreClient is the contract an external secret store has to provide to receive credentials
+type SecretStoreClient struct {
+	GetLocation  func(key string) string
+	PutSecret    func(key string, secret json.Map) []error
+	SecretExists func(key string) (bool, []error)
+	DeleteSecret func(key string) []error
+}
+
+// NewSecretStoreCredentialSink stores every credential under key_prefix + the credential name
+func NewSecretStoreCredentialSink(secret_store_client SecretStoreClient, key_prefix string) (*CredentialSink, []error) {
+	var errors []error
+	if secret_store_client.PutSecret == nil || secret_store_client.SecretExists == nil || secret_store_client.DeleteSecret == nil || secret_store_client.GetLocation == nil {
+		errors = append(errors, fmt.Errorf("secret store client is missing functions"))
+		return nil, errors
+	}
+
+	getKey := func(credential DatabaseCredential) string {
+		return key_prefix + credential.GetName()
+	}
+
+	return &CredentialSink{
+		GetName: func() string {
+			return "secret-store"
+		},
+		GetLocation: func(credential DatabaseCredential) (string, []error) {
+			return secret_store_client.GetLocation(getKey(credential)), nil
+		},
+		Exists: func(credential DatabaseCredential) (bool, []error) {
+			return secret_store_client.SecretExists(getKey(credential))
+		},
+		Write: func(credential DatabaseCredential) []error {
+			return secret_store_client.PutSecret(getKey(credential), getCredentialMap(credential))
+		},
+		Remove: func(credential DatabaseCredential) []error {
+			return secret_store_client.DeleteSecret(getKey(credential))
+		},
+		// a secret store client has no read, secrets are neither verified nor restored once they existed
+		Verify: func(credential DatabaseCredential) ([]string, []error) {
+			return nil, nil
+		},
+		Snapshot: func(credential DatabaseCredential) (func() []error, []error) {
+			exists, exists_errors := secret_store_client.SecretExists(getKey(credential))
+			if exists_errors != nil {
+				return nil, exists_errors
+			}
+
+			if exists {
+				return func() []error {
+					var errors []error
+					errors = append(errors, fmt.Errorf("secret: %s was replaced and cannot be restored, the secret store has no read", secret_store_client.GetLocation(getKey(credential))))
+					return errors
+				}, nil
+			}
+
+			return func() []error {
+				return secret_store_client.DeleteSecret(getKey(credential))
+			}, nil
+		},
+	}, nil
+}
+
+// NewFileSecretStoreClient keeps every secret in one local JSON file, a stand-in for a real secret store in tests
+func NewFileSecretStoreClient(directory string, filename string) (*SecretStoreClient, []error) {
+	lock := &sync.Mutex{}
+	host_client_instance, host_client_errors := host_client.NewHostClient()
+	if host_client_errors != nil {
+		return nil, host_client_errors
+	}
+
+	store_directory, store_directory_errors := getAbsoluteDirectory(host_client_instance, directory)
+	if store_directory_errors != nil {
+		return nil, store_directory_errors
+	}
+
+	store_file, store_file_errors := host_client_instance.AbsoluteFile(*store_directory, filename)
+	if store_file_errors != nil {
+		return nil, store_file_errors
+	}
+
+	readSecrets := func() (*json.Map, []error) {
+		if !store_file.Exists() {
+			return json.NewMap(), nil
+		}
+
+		lines, lines_errors := store_file.ReadAllAsStringArray()
+		if lines_errors != nil {
+			return nil, lines_errors
+		}
+
+		content := strings.TrimSpace(strings.Join(*lines, "\n"))
+		if content == "" {
+			return json.NewMap(), nil
+		}
+		return json.Parse(content)
+	}
+
+	writeSecrets := func(secrets *json.Map) []error {
+		var json_payload strings.Builder
+		json_errors := secrets.ToJSONString(&json_payload)
+		if json_errors != nil {
+			return json_errors
+		}
+
+		create_directory_errors := store_directory.CreateIfDoesNotExist()
+		if create_directory_errors != nil {
+			return create_directory_errors
+		}
+
+		remove_file_errors := store_file.RemoveIfExists()
+		if remove_file_errors != nil {
+			return remove_file_errors
+		}
+
+		create_file_errors := store_file.Create()
+		if create_file_errors != nil {
+			return create_file_errors
+		}
+
+		return store_file.Append(json_payload.String())
+	}
+
+	return &SecretStoreClient{
+		GetLocation: func(key string) string {
+			return store_file.GetPathAsString() + "#" + key
+		},
+		PutSecret: func(key string, secret json.Map) []error {
+			lock.Lock()
+			defer lock.Unlock()
+			secrets, secrets_errors := readSecrets()
+			if secrets_errors != nil {
+				return secrets_errors
+			}
+			secrets.SetMapValue(key, secret)
+			return writeSecrets(secrets)
+		},
+		SecretExists: func(key string) (bool, []error) {
+			lock.Lock()
+			defer lock.Unlock()
+			secrets, secrets_errors := readSecrets()
+			if secrets_errors != nil {
+				return false, secrets_errors
+			}
+			return secrets.HasKey(key), nil
+		},
+		DeleteSecret: func(key string) []error {
+			lock.Lock()
+			defer lock.Unlock()
+			secrets, secrets_errors := readSecrets()
+			if secrets_errors != nil {
+				return secrets_errors
+			}
+
+			if !secrets.HasKey(key) {
+				return nil
+			}
+
+			_, remove_error := secrets.RemoveKey(key)
+			if remove_error != nil {
+				return []error{remove_error}
+			}
+			return writeSecrets(secrets)
+		},
+	}, nil
+}
@@ -0,0 +1,52 @@
+package db_installer
+
+import (
+	"strconv"
+	"strings"
+
+	json "github.com/matehaxor03/holistic_json/json"
+)
+
+func getCredentialMap(credential DatabaseCredential) json.Map {
+	credential_map := json.NewMapValue()
+	credential_map.SetStringValue("host_name", credential.GetHostName())
+	credential_map.SetStringValue("port_number", credential.GetPortNumber())
+	credential_map.SetStringValue("database_name", credential.GetDatabaseName())
+	credential_map.SetStringValue("username", credential.GetUsername())
+	if credential.HasPassword() {
+		credential_map.SetStringValue("password", credential.GetPassword())
+	}
+	return credential_map
+}
+
+func getCredentialYAML(credential DatabaseCredential, indent string) string {
+	var yaml strings.Builder
+	yaml.WriteString(indent + "host_name: " + strconv.Quote(credential.GetHostName()) + "\n")
+	yaml.WriteString(indent + "port_number: " + strconv.Quote(credential.GetPortNumber()) + "\n")
+	yaml.WriteString(indent + "database_name: " + strconv.Quote(credential.GetDatabaseName()) + "\n")
+	yaml.WriteString(indent + "username: " + strconv.Quote(credential.GetUsername()) + "\n")
+	if credential.HasPassword() {
+		yaml.WriteString(indent + "password: " + strconv.Quote(credential.GetPassword()) + "\n")
+	}
+	return yaml.String()
+}
+
+// NewJSONSecretsCredentialSink writes one JSON secrets file per credential into directory
+func NewJSONSecretsCredentialSink(directory string) (*CredentialSink, []error) {
+	return newDirectoryCredentialSink("json", directory, ".json", func(credential DatabaseCredential) (string, []error) {
+		credential_map := getCredentialMap(credential)
+		var json_payload strings.Builder
+		json_errors := credential_map.ToJSONString(&json_payload)
+		if json_errors != nil {
+			return "", json_errors
+		}
+		return json_payload.String() + "\n", nil
+	})
+}
+
+// NewYAMLSecretsCredentialSink writes one YAML secrets file per credential into directory
+func NewYAMLSecretsCredentialSink(directory string) (*CredentialSink, []error) {
+	return newDirectoryCredentialSink("yaml", directory, ".yaml", func(credential DatabaseCredential) (string, []error) {
+		return getCredentialYAML(credential, ""), nil
+	})
+}
@@ -0,0 +1,58 @@
+package db_installer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TLSRequirement is the REQUIRE clause of the accounts of a role, SSL only asks for an encrypted connection,
+// X509 also asks for a client certificate and an issuer or subject asks for one from that issuer or subject
+type TLSRequirement struct {
+	GetRequire func() string
+	GetIssuer  func() string
+	GetSubject func() string
+	Validate   func() []error
+}
+
+func getTLSRequirements() []string {
+	return []string{"NONE", "SSL", "X509"}
+}
+
+func NewTLSRequirement(require string, issuer string, subject string) *TLSRequirement {
+	tls_require := strings.ToUpper(strings.TrimSpace(require))
+	if tls_require == "" && (issuer != "" || subject != "") {
+		tls_require = "X509"
+	}
+
+	validate := func() []error {
+		var errors []error
+		if !containsString(getTLSRequirements(), tls_require) {
+			errors = append(errors, fmt.Errorf("tls require: %s is not supported, use one of %s", tls_require, strings.Join(getTLSRequirements(), ", ")))
+		}
+
+		if (issuer != "" || subject != "") && tls_require != "X509" {
+			errors = append(errors, fmt.Errorf("tls require: %s cannot be combined with an issuer or subject, they need X509", tls_require))
+		}
+
+		if len(errors) > 0 {
+			return errors
+		}
+
+		return nil
+	}
+
+	return &TLSRequirement{
+		GetRequire: func() string {
+			return tls_require
+		},
+		GetIssuer: func() string {
+			return issuer
+		},
+		GetSubject: func() string {
+			return subject
+		},
+		Validate: func() []error {
+			return validate()
+		},
+	}
+}
@@ -0,0 +1,68 @@
+package db_installer
+
+import (
+	"sync"
+)
+
+// WorkerPool runs the tasks of one user each on at most concurrency workers. a failing task does not stop the others,
+// Wait returns the errors of all tasks in the order they were submitted. every worker has a SQLCommand of its own
+// because the host client runs the commands of one host user one at a time
+type WorkerPool struct {
+	Submit func(task func(sql_command *SQLCommand) []error)
+	Wait   func() []error
+}
+
+type workerPoolTask struct {
+	index int
+	run   func(sql_command *SQLCommand) []error
+}
+
+func newWorkerPool(concurrency int, newWorkerSQLCommand func() (*SQLCommand, []error)) *WorkerPool {
+	lock := &sync.Mutex{}
+	var wait_group sync.WaitGroup
+	tasks := make(chan workerPoolTask)
+	var task_errors [][]error
+
+	for worker := 0; worker < concurrency; worker++ {
+		wait_group.Add(1)
+		go func() {
+			defer wait_group.Done()
+			sql_command, sql_command_errors := newWorkerSQLCommand()
+			for task := range tasks {
+				run_errors := sql_command_errors
+				if sql_command_errors == nil {
+					run_errors = task.run(sql_command)
+				}
+
+				lock.Lock()
+				task_errors[task.index] = run_errors
+				lock.Unlock()
+			}
+		}()
+	}
+
+	return &WorkerPool{
+		Submit: func(task func(sql_command *SQLCommand) []error) {
+			lock.Lock()
+			index := len(task_errors)
+			task_errors = append(task_errors, nil)
+			lock.Unlock()
+			tasks <- workerPoolTask{index: index, run: task}
+		},
+		Wait: func() []error {
+			close(tasks)
+			wait_group.Wait()
+
+			var errors []error
+			for _, run_errors := range task_errors {
+				errors = append(errors, run_errors...)
+			}
+
+			if len(errors) > 0 {
+				return errors
+			}
+
+			return nil
+		},
+	}
+}
@@ -1,116 +1,781 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
-	host_client "github.com/matehaxor03/holistic_host_client/host_client"
+
 	common "github.com/matehaxor03/holistic_common/common"
 	db_installer "github.com/matehaxor03/holistic_db_init/db_installer"
+	host_client "github.com/matehaxor03/holistic_host_client/host_client"
 )
 
-func main() {
+func EXIT_CODE_SUCCESS() int {
+	return 0
+}
+
+func EXIT_CODE_ERROR() int {
+	return 1
+}
+
+func EXIT_CODE_USAGE() int {
+	return 2
+}
+
+func EXIT_CODE_DRIFT() int {
+	return 3
+}
+
+type Command struct {
+	GetName        func() string
+	GetDescription func() string
+	Run            func(arguments []string) int
+}
+
+func newCommand(name string, description string, run func(flags *flag.FlagSet, arguments []string) int) Command {
+	return Command{
+		GetName: func() string {
+			return name
+		},
+		GetDescription: func() string {
+			return description
+		},
+		Run: func(arguments []string) int {
+			flags := flag.NewFlagSet(name, flag.ContinueOnError)
+			flags.Usage = func() {
+				fmt.Fprintf(flags.Output(), "usage: holistic_db_init %s [flags]\n\n%s\n\nflags:\n", name, description)
+				flags.PrintDefaults()
+			}
+			return run(flags, arguments)
+		},
+	}
+}
+
+type installerFlags struct {
+	config_path         *string
+	host_name           *string
+	port_number         *string
+	database_name       *string
+	root_username       *string
+	root_password_file  *string
+	writer_usernames    *string
+	reader_usernames    *string
+	migration_usernames *string
+	write_pool_size     *string
+	read_pool_size      *string
+	concurrency         *string
+	credential_sinks    *string
+	tls_ca              *string
+	tls_cert            *string
+	tls_key             *string
+	tls_mode            *string
+	checkpoint_file     *string
+	log_format          *string
+	log_level           *string
+}
+
+func addInstallerFlags(flags *flag.FlagSet) installerFlags {
+	return installerFlags{
+		config_path:         flags.String("config", "", "json installer config file, flags and environment variables override single fields"),
+		host_name:           flags.String("host", "", "database host name (overrides "+common.ENV_HOLISTIC_DATABASE_HOSTNAME()+")"),
+		port_number:         flags.String("port", "", "database port number (overrides "+common.ENV_HOLISTIC_DATABASE_PORT_NUMBER()+")"),
+		database_name:       flags.String("database", "", "database name (overrides "+common.ENV_HOLISTIC_DATABASE_NAME()+")"),
+		root_username:       flags.String("root-username", "", "database root username (overrides "+common.ENV_HOLISTIC_DATABASE_ROOT_USERNAME()+")"),
+		root_password_file:  flags.String("root-password-file", "", "file holding the database root password, - reads it from stdin (overrides "+common.ENV_HOLISTIC_DATABASE_ROOT_PASSWORD()+")"),
+		writer_usernames:    flags.String("writer-usernames", "", "comma separated host users receiving write credentials (overrides "+common.ENV_HOLISTIC_DATABASE_WRITER_USERNAMES()+")"),
+		reader_usernames:    flags.String("reader-usernames", "", "comma separated host users receiving read credentials (overrides "+common.ENV_HOLISTIC_DATABASE_READER_USERNAMES()+")"),
+		migration_usernames: flags.String("migration-usernames", "", "comma separated host users receiving migration credentials (overrides "+common.ENV_HOLISTIC_DATABASE_MIGRATION_USERNAMES()+")"),
+		write_pool_size:     flags.String("write-pool-size", "", fmt.Sprintf("number of pooled write users (overrides %s, default %d)", db_installer.ENV_HOLISTIC_DATABASE_WRITE_POOL_SIZE(), db_installer.POOL_SIZE_DEFAULT())),
+		read_pool_size:      flags.String("read-pool-size", "", fmt.Sprintf("number of pooled read users (overrides %s, default %d)", db_installer.ENV_HOLISTIC_DATABASE_READ_POOL_SIZE(), db_installer.POOL_SIZE_DEFAULT())),
+		concurrency:         flags.String("concurrency", "", fmt.Sprintf("number of users provisioned at the same time (overrides %s, default %d)", db_installer.ENV_HOLISTIC_DATABASE_CONCURRENCY(), db_installer.CONCURRENCY_DEFAULT())),
+		credential_sinks:    flags.String("credential-sinks", "", "comma separated type[:location[:namespace]] of option-file, dotenv, json, yaml, kubernetes-secret or secret-store-file the role credentials are written to, root credentials are always option files (overrides "+db_installer.ENV_HOLISTIC_DATABASE_CREDENTIAL_SINKS()+", default option-file)"),
+		tls_ca:              flags.String("tls-ca", "", "ca file the installer and the credential files verify the server with (overrides "+db_installer.ENV_HOLISTIC_DATABASE_TLS_CA()+")"),
+		tls_cert:            flags.String("tls-cert", "", "client certificate file the installer connects with (overrides "+db_installer.ENV_HOLISTIC_DATABASE_TLS_CERT()+")"),
+		tls_key:             flags.String("tls-key", "", "client key file the installer connects with (overrides "+db_installer.ENV_HOLISTIC_DATABASE_TLS_KEY()+")"),
+		tls_mode:            flags.String("tls-mode", "", "ssl-mode: REQUIRED, VERIFY_CA or VERIFY_IDENTITY (overrides "+db_installer.ENV_HOLISTIC_DATABASE_TLS_MODE()+", default "+db_installer.TLS_MODE_DEFAULT()+")"),
+		checkpoint_file:     flags.String("checkpoint-file", "", "file install keeps its progress in for --resume (default ~/.db/holistic_db_install#<host>#<port>#<database>.checkpoint)"),
+		log_format:          flags.String("log-format", db_installer.LOG_FORMAT_TEXT(), "log output format written to stderr: text or json"),
+		log_level:           flags.String("log-level", "info", "minimum log level: debug, info, warn or error"),
+	}
+}
+
+func getFlagOrEnviornmentVariable(host_client *host_client.HostClient, flag_value *string, environment_variable_name string, config_value string) (*string, []error) {
+	if flag_value != nil && *flag_value != "" {
+		return flag_value, nil
+	}
+
+	if _, found := os.LookupEnv(environment_variable_name); !found && config_value != "" {
+		return &config_value, nil
+	}
+	return host_client.GetEnviornmentVariable(environment_variable_name)
+}
+
+// getOptionalFlagOrEnviornmentVariable is getFlagOrEnviornmentVariable for settings that may be left empty
+func getOptionalFlagOrEnviornmentVariable(host_client *host_client.HostClient, flag_value *string, environment_variable_name string, config_value string) (string, []error) {
+	if flag_value != nil && *flag_value != "" {
+		return *flag_value, nil
+	}
+
+	if _, found := os.LookupEnv(environment_variable_name); !found {
+		return config_value, nil
+	}
+
+	value, value_errors := host_client.GetEnviornmentVariable(environment_variable_name)
+	if value_errors != nil {
+		return "", value_errors
+	}
+	return *value, nil
+}
+
+func getFlagOrEnviornmentVariableNumber(host_client *host_client.HostClient, name string, flag_value *string, environment_variable_name string, config_value int) (int, []error) {
+	var errors []error
+	var raw_value *string
+	if flag_value != nil && *flag_value != "" {
+		raw_value = flag_value
+	} else if _, found := os.LookupEnv(environment_variable_name); !found {
+		return config_value, nil
+	} else {
+		temp_raw_value, raw_value_errors := host_client.GetEnviornmentVariable(environment_variable_name)
+		if raw_value_errors != nil {
+			return 0, raw_value_errors
+		}
+		raw_value = temp_raw_value
+	}
+
+	value, value_error := strconv.Atoi(strings.TrimSpace(*raw_value))
+	if value_error != nil {
+		errors = append(errors, fmt.Errorf("%s: %s is not a number", name, *raw_value))
+		return 0, errors
+	}
+
+	return value, nil
+}
+
+// readRootPasswordFile reads the root password from a file or, for -, from stdin so it never shows up in ps or the shell history
+func readRootPasswordFile(path string) (string, []error) {
+	var errors []error
+	var raw_password []byte
+	var read_error error
+	if path == "-" {
+		raw_password, read_error = io.ReadAll(os.Stdin)
+	} else {
+		raw_password, read_error = os.ReadFile(path)
+	}
+
+	if read_error != nil {
+		errors = append(errors, fmt.Errorf("root password file: %s could not be read: %s", path, read_error))
+		return "", errors
+	}
+
+	password := strings.TrimRight(string(raw_password), "\r\n")
+	if password == "" {
+		errors = append(errors, fmt.Errorf("root password file: %s is empty", path))
+		return "", errors
+	}
+	return password, nil
+}
+
+func splitHostUsernames(raw_host_usernames string) []string {
+	var host_usernames []string
+	if strings.Contains(raw_host_usernames, ",") {
+		temp := strings.Split(raw_host_usernames, ",")
+		host_usernames = append(host_usernames, temp...)
+	} else {
+		host_usernames = append(host_usernames, raw_host_usernames)
+	}
+	return host_usernames
+}
+
+func newDatabaseInstaller(installer_flags installerFlags) (*db_installer.DatabaseInstaller, []error) {
 	var errors []error
 	host_client, host_client_errors := host_client.NewHostClient()
 	if host_client_errors != nil {
-		fmt.Println(fmt.Errorf("%s", host_client_errors))
-		os.Exit(1)
+		return nil, host_client_errors
+	}
+
+	config := db_installer.NewInstallerConfig()
+	if *installer_flags.config_path != "" {
+		loaded_config, loaded_config_errors := db_installer.LoadInstallerConfig(*installer_flags.config_path)
+		if loaded_config_errors != nil {
+			return nil, loaded_config_errors
+		}
+		config = loaded_config
 	}
 
-	database_host_name, database_host_name_errors := host_client.GetEnviornmentVariable(common.ENV_HOLISTIC_DATABASE_HOSTNAME())
+	database_host_name, database_host_name_errors := getFlagOrEnviornmentVariable(host_client, installer_flags.host_name, common.ENV_HOLISTIC_DATABASE_HOSTNAME(), config.GetDatabaseHostName())
 	if database_host_name_errors != nil {
 		errors = append(errors, database_host_name_errors...)
 	}
 
-	database_port_number, database_port_number_errors := host_client.GetEnviornmentVariable(common.ENV_HOLISTIC_DATABASE_PORT_NUMBER())
+	database_port_number, database_port_number_errors := getFlagOrEnviornmentVariable(host_client, installer_flags.port_number, common.ENV_HOLISTIC_DATABASE_PORT_NUMBER(), config.GetDatabasePortNumber())
 	if database_port_number_errors != nil {
 		errors = append(errors, database_port_number_errors...)
 	}
 
-	database_name, database_name_errors := host_client.GetEnviornmentVariable(common.ENV_HOLISTIC_DATABASE_NAME())
+	database_name, database_name_errors := getFlagOrEnviornmentVariable(host_client, installer_flags.database_name, common.ENV_HOLISTIC_DATABASE_NAME(), config.GetDatabaseName())
 	if database_name_errors != nil {
 		errors = append(errors, database_name_errors...)
 	}
 
-	database_root_username, database_root_username_errors := host_client.GetEnviornmentVariable(common.ENV_HOLISTIC_DATABASE_ROOT_USERNAME())
+	database_root_username, database_root_username_errors := getFlagOrEnviornmentVariable(host_client, installer_flags.root_username, common.ENV_HOLISTIC_DATABASE_ROOT_USERNAME(), config.GetDatabaseRootUsername())
 	if database_root_username_errors != nil {
 		errors = append(errors, database_root_username_errors...)
 	}
 
-	database_root_password, database_root_password_errors := host_client.GetEnviornmentVariable(common.ENV_HOLISTIC_DATABASE_ROOT_PASSWORD())
+	// the root password is only needed where the root option files are written, export runs without it
+	var database_root_password string
+	var database_root_password_errors []error
+	if *installer_flags.root_password_file != "" {
+		database_root_password, database_root_password_errors = readRootPasswordFile(*installer_flags.root_password_file)
+	} else {
+		database_root_password, database_root_password_errors = getOptionalFlagOrEnviornmentVariable(host_client, nil, common.ENV_HOLISTIC_DATABASE_ROOT_PASSWORD(), config.GetDatabaseRootPassword())
+	}
 	if database_root_password_errors != nil {
 		errors = append(errors, database_root_password_errors...)
 	}
 
-
-	writer_raw_host_usernames, writer_raw_host_usernames_errors := host_client.GetEnviornmentVariable(common.ENV_HOLISTIC_DATABASE_WRITER_USERNAMES())
+	writer_raw_host_usernames, writer_raw_host_usernames_errors := getFlagOrEnviornmentVariable(host_client, installer_flags.writer_usernames, common.ENV_HOLISTIC_DATABASE_WRITER_USERNAMES(), strings.Join(config.GetRole(db_installer.ROLE_WRITE()).GetHostUsers(), ","))
 	if writer_raw_host_usernames_errors != nil {
 		errors = append(errors, writer_raw_host_usernames_errors...)
 	}
 
-	reader_raw_host_usernames, reader_raw_host_usernames_errors := host_client.GetEnviornmentVariable(common.ENV_HOLISTIC_DATABASE_READER_USERNAMES())
+	reader_raw_host_usernames, reader_raw_host_usernames_errors := getFlagOrEnviornmentVariable(host_client, installer_flags.reader_usernames, common.ENV_HOLISTIC_DATABASE_READER_USERNAMES(), strings.Join(config.GetRole(db_installer.ROLE_READ()).GetHostUsers(), ","))
 	if reader_raw_host_usernames_errors != nil {
 		errors = append(errors, reader_raw_host_usernames_errors...)
 	}
 
-	migration_raw_host_usernames, migration_raw_host_usernames_errors := host_client.GetEnviornmentVariable(common.ENV_HOLISTIC_DATABASE_MIGRATION_USERNAMES())
+	migration_raw_host_usernames, migration_raw_host_usernames_errors := getFlagOrEnviornmentVariable(host_client, installer_flags.migration_usernames, common.ENV_HOLISTIC_DATABASE_MIGRATION_USERNAMES(), strings.Join(config.GetRole(db_installer.ROLE_MIGRATION()).GetHostUsers(), ","))
 	if migration_raw_host_usernames_errors != nil {
 		errors = append(errors, migration_raw_host_usernames_errors...)
 	}
 
-	if len(errors) > 0 {
-		fmt.Println(fmt.Errorf("%s", errors))
-		os.Exit(1)
+	write_pool_size, write_pool_size_errors := getFlagOrEnviornmentVariableNumber(host_client, "pool size", installer_flags.write_pool_size, db_installer.ENV_HOLISTIC_DATABASE_WRITE_POOL_SIZE(), config.GetRole(db_installer.ROLE_WRITE()).GetPoolSize())
+	if write_pool_size_errors != nil {
+		errors = append(errors, write_pool_size_errors...)
+	}
+
+	read_pool_size, read_pool_size_errors := getFlagOrEnviornmentVariableNumber(host_client, "pool size", installer_flags.read_pool_size, db_installer.ENV_HOLISTIC_DATABASE_READ_POOL_SIZE(), config.GetRole(db_installer.ROLE_READ()).GetPoolSize())
+	if read_pool_size_errors != nil {
+		errors = append(errors, read_pool_size_errors...)
 	}
 
-	var writer_host_usernames []string
-	var reader_host_usernames []string
-	var migration_host_usernames []string
+	concurrency, concurrency_errors := getFlagOrEnviornmentVariableNumber(host_client, "concurrency", installer_flags.concurrency, db_installer.ENV_HOLISTIC_DATABASE_CONCURRENCY(), config.GetConcurrency())
+	if concurrency_errors != nil {
+		errors = append(errors, concurrency_errors...)
+	}
 
-	{
-		if strings.Contains(*writer_raw_host_usernames, ",") {
-			temp := strings.Split(*writer_raw_host_usernames, ",")
-			writer_host_usernames = append(writer_host_usernames, temp...)
+	raw_credential_sinks := ""
+	if *installer_flags.credential_sinks != "" {
+		raw_credential_sinks = *installer_flags.credential_sinks
+	} else if _, found := os.LookupEnv(db_installer.ENV_HOLISTIC_DATABASE_CREDENTIAL_SINKS()); found {
+		temp_raw_credential_sinks, raw_credential_sinks_errors := host_client.GetEnviornmentVariable(db_installer.ENV_HOLISTIC_DATABASE_CREDENTIAL_SINKS())
+		if raw_credential_sinks_errors != nil {
+			errors = append(errors, raw_credential_sinks_errors...)
 		} else {
-			writer_host_usernames = append(writer_host_usernames, *writer_raw_host_usernames)
+			raw_credential_sinks = *temp_raw_credential_sinks
 		}
 	}
 
-	{
-		if strings.Contains(*reader_raw_host_usernames, ",") {
-			temp := strings.Split(*reader_raw_host_usernames, ",")
-			reader_host_usernames = append(reader_host_usernames, temp...)
+	if raw_credential_sinks != "" {
+		credential_sinks, credential_sinks_errors := db_installer.ParseCredentialSinks(raw_credential_sinks)
+		if credential_sinks_errors != nil {
+			errors = append(errors, credential_sinks_errors...)
 		} else {
-			reader_host_usernames = append(reader_host_usernames, *reader_raw_host_usernames)
+			config.SetCredentialSinks(credential_sinks)
 		}
 	}
 
+	config_client_tls := db_installer.NewClientTLS("", "", "", "")
+	if config.GetClientTLS() != nil {
+		config_client_tls = config.GetClientTLS()
+	}
 
-	{
-		if strings.Contains(*migration_raw_host_usernames, ",") {
-			temp := strings.Split(*migration_raw_host_usernames, ",")
-			migration_host_usernames = append(migration_host_usernames, temp...)
-		} else {
-			migration_host_usernames = append(migration_host_usernames, *migration_raw_host_usernames)
+	var tls_settings []string
+	for _, tls_setting := range []struct {
+		flag_value                *string
+		environment_variable_name string
+		config_value              string
+	}{
+		{installer_flags.tls_ca, db_installer.ENV_HOLISTIC_DATABASE_TLS_CA(), config_client_tls.GetCA()},
+		{installer_flags.tls_cert, db_installer.ENV_HOLISTIC_DATABASE_TLS_CERT(), config_client_tls.GetCert()},
+		{installer_flags.tls_key, db_installer.ENV_HOLISTIC_DATABASE_TLS_KEY(), config_client_tls.GetKey()},
+		{installer_flags.tls_mode, db_installer.ENV_HOLISTIC_DATABASE_TLS_MODE(), config_client_tls.GetMode()},
+	} {
+		tls_setting_value, tls_setting_errors := getOptionalFlagOrEnviornmentVariable(host_client, tls_setting.flag_value, tls_setting.environment_variable_name, tls_setting.config_value)
+		if tls_setting_errors != nil {
+			errors = append(errors, tls_setting_errors...)
 		}
+		tls_settings = append(tls_settings, tls_setting_value)
+	}
+
+	logger, logger_errors := db_installer.NewLogger(os.Stderr, *installer_flags.log_format, *installer_flags.log_level)
+	if logger_errors != nil {
+		errors = append(errors, logger_errors...)
 	}
 
 	if len(errors) > 0 {
-		fmt.Println(fmt.Errorf("%s", errors))
-		os.Exit(1)
+		return nil, errors
+	}
+
+	config.SetDatabaseHostName(*database_host_name)
+	config.SetDatabasePortNumber(*database_port_number)
+	config.SetDatabaseName(*database_name)
+	config.SetDatabaseRootUsername(*database_root_username)
+	config.SetDatabaseRootPassword(database_root_password)
+	config.GetRole(db_installer.ROLE_WRITE()).SetHostUsers(splitHostUsernames(*writer_raw_host_usernames))
+	config.GetRole(db_installer.ROLE_READ()).SetHostUsers(splitHostUsernames(*reader_raw_host_usernames))
+	config.GetRole(db_installer.ROLE_MIGRATION()).SetHostUsers(splitHostUsernames(*migration_raw_host_usernames))
+	config.GetRole(db_installer.ROLE_WRITE()).SetPoolSize(write_pool_size)
+	config.GetRole(db_installer.ROLE_READ()).SetPoolSize(read_pool_size)
+	config.SetConcurrency(concurrency)
+	if *installer_flags.checkpoint_file != "" {
+		config.SetCheckpointFile(*installer_flags.checkpoint_file)
+	}
+	if config.GetClientTLS() != nil || tls_settings[0] != "" || tls_settings[1] != "" || tls_settings[2] != "" || tls_settings[3] != config_client_tls.GetMode() {
+		config.SetClientTLS(db_installer.NewClientTLS(tls_settings[0], tls_settings[1], tls_settings[2], tls_settings[3]))
 	}
 
-	database_installer,  database_installer_errors := db_installer.NewDatabaseInstaller(*database_host_name, *database_port_number, *database_name, *database_root_username, *database_root_password, writer_host_usernames, reader_host_usernames, migration_host_usernames)
-	if database_installer_errors != nil {
-		fmt.Println(fmt.Errorf("%s", database_installer_errors))
-		os.Exit(1)
+	return db_installer.NewDatabaseInstallerFromConfig(config, logger)
+}
+
+func parseFlags(flags *flag.FlagSet, arguments []string) (bool, int) {
+	parse_error := flags.Parse(arguments)
+	if parse_error == flag.ErrHelp {
+		return false, EXIT_CODE_SUCCESS()
+	} else if parse_error != nil {
+		return false, EXIT_CODE_USAGE()
+	} else if flags.NArg() > 0 {
+		fmt.Fprintf(flags.Output(), "unexpected arguments: %s\n", strings.Join(flags.Args(), " "))
+		flags.Usage()
+		return false, EXIT_CODE_USAGE()
 	}
+	return true, EXIT_CODE_SUCCESS()
+}
+
+func printErrors(errors []error) int {
+	fmt.Fprintln(os.Stderr, fmt.Errorf("%s", errors))
+	return EXIT_CODE_ERROR()
+}
+
+func getCommands() []Command {
+	var commands []Command
+
+	commands = append(commands, newCommand("install", "create the database, mysql roles, users, grants, credential files and the DatabaseMigration table", func(flags *flag.FlagSet, arguments []string) int {
+		installer_flags := addInstallerFlags(flags)
+		dry_run := flags.Bool("dry-run", false, "print the actions install would take without changing anything")
+		report_format := flags.String("report-format", "text", "install report format printed to stdout: text or json")
+		report_file := flags.String("report-file", "", "also write the install report as json to this path")
+		resume := flags.Bool("resume", false, "continue an interrupted install from its checkpoint, finished users keep their passwords. a failed install rolls its run back, so there is little left to skip")
+		if ok, exit_code := parseFlags(flags, arguments); !ok {
+			return exit_code
+		}
+
+		if *report_format != "text" && *report_format != "json" {
+			fmt.Fprintf(flags.Output(), "unknown report format: %s\n", *report_format)
+			return EXIT_CODE_USAGE()
+		}
+
+		if *dry_run && *resume {
+			fmt.Fprintln(flags.Output(), "--dry-run and --resume cannot be combined")
+			return EXIT_CODE_USAGE()
+		}
+
+		database_installer, database_installer_errors := newDatabaseInstaller(installer_flags)
+		if database_installer_errors != nil {
+			return printErrors(database_installer_errors)
+		}
+
+		if *dry_run {
+			install_plan, install_plan_errors := database_installer.Plan()
+			if install_plan_errors != nil {
+				return printErrors(install_plan_errors)
+			}
+
+			fmt.Print(install_plan.ToString())
+			return EXIT_CODE_SUCCESS()
+		}
+
+		var install_report *db_installer.InstallReport
+		var install_errors []error
+		if *resume {
+			install_report, install_errors = database_installer.Resume()
+		} else {
+			install_report, install_errors = database_installer.Install()
+		}
+
+		var json_payload strings.Builder
+		json_errors := install_report.ToJSONString(&json_payload)
+		if json_errors != nil {
+			return printErrors(json_errors)
+		}
+
+		if *report_file != "" {
+			write_file_error := os.WriteFile(*report_file, []byte(json_payload.String()+"\n"), 0600)
+			if write_file_error != nil {
+				return printErrors([]error{write_file_error})
+			}
+		}
+
+		if *report_format == "json" {
+			fmt.Println(json_payload.String())
+		} else {
+			fmt.Print(install_report.ToString())
+		}
+
+		if install_errors != nil {
+			return printErrors(install_errors)
+		}
+		return EXIT_CODE_SUCCESS()
+	}))
+
+	commands = append(commands, newCommand("validate", "validate the installer settings without connecting to the database", func(flags *flag.FlagSet, arguments []string) int {
+		installer_flags := addInstallerFlags(flags)
+		if ok, exit_code := parseFlags(flags, arguments); !ok {
+			return exit_code
+		}
 
-	install_errors := database_installer.Install()
-	if install_errors != nil {
-		fmt.Println(fmt.Errorf("%s", install_errors))
-		os.Exit(1)
+		database_installer, database_installer_errors := newDatabaseInstaller(installer_flags)
+		if database_installer_errors != nil {
+			return printErrors(database_installer_errors)
+		}
+
+		validate_errors := database_installer.Validate()
+		if validate_errors != nil {
+			return printErrors(validate_errors)
+		}
+
+		fmt.Println("valid")
+		return EXIT_CODE_SUCCESS()
+	}))
+
+	commands = append(commands, newCommand("plan", "print the actions install would take without changing anything", func(flags *flag.FlagSet, arguments []string) int {
+		installer_flags := addInstallerFlags(flags)
+		output_format := flags.String("format", "text", "output format: text or json")
+		if ok, exit_code := parseFlags(flags, arguments); !ok {
+			return exit_code
+		}
+
+		if *output_format != "text" && *output_format != "json" {
+			fmt.Fprintf(flags.Output(), "unknown format: %s\n", *output_format)
+			return EXIT_CODE_USAGE()
+		}
+
+		database_installer, database_installer_errors := newDatabaseInstaller(installer_flags)
+		if database_installer_errors != nil {
+			return printErrors(database_installer_errors)
+		}
+
+		install_plan, install_plan_errors := database_installer.Plan()
+		if install_plan_errors != nil {
+			return printErrors(install_plan_errors)
+		}
+
+		if *output_format == "json" {
+			var json_payload strings.Builder
+			json_errors := install_plan.ToJSONString(&json_payload)
+			if json_errors != nil {
+				return printErrors(json_errors)
+			}
+			fmt.Println(json_payload.String())
+		} else {
+			fmt.Print(install_plan.ToString())
+		}
+		return EXIT_CODE_SUCCESS()
+	}))
+
+	commands = append(commands, newCommand("export", "write the sql install would run on a new database to a file for review, with passwords from a secrets file", func(flags *flag.FlagSet, arguments []string) int {
+		installer_flags := addInstallerFlags(flags)
+		output := flags.String("output", "", "sql file to write, it holds the passwords and is only readable by its owner")
+		secrets_file := flags.String("secrets-file", "", "json file with the passwords by database username, users missing from it get a generated password that is added to it")
+		if ok, exit_code := parseFlags(flags, arguments); !ok {
+			return exit_code
+		}
+
+		if *output == "" || *secrets_file == "" {
+			fmt.Fprintln(flags.Output(), "--output and --secrets-file are required")
+			return EXIT_CODE_USAGE()
+		}
+
+		database_installer, database_installer_errors := newDatabaseInstaller(installer_flags)
+		if database_installer_errors != nil {
+			return printErrors(database_installer_errors)
+		}
+
+		script, export_errors := database_installer.ExportSQL(*secrets_file)
+		if export_errors != nil {
+			return printErrors(export_errors)
+		}
+
+		write_file_error := os.WriteFile(*output, []byte(script), 0600)
+		if write_file_error != nil {
+			return printErrors([]error{write_file_error})
+		}
+		return EXIT_CODE_SUCCESS()
+	}))
+
+	commands = append(commands, newCommand("status", "show the database, users, grants, credential files and settings compared to what install would create", func(flags *flag.FlagSet, arguments []string) int {
+		installer_flags := addInstallerFlags(flags)
+		output_format := flags.String("format", "text", "output format: text or json")
+		if ok, exit_code := parseFlags(flags, arguments); !ok {
+			return exit_code
+		}
+
+		if *output_format != "text" && *output_format != "json" {
+			fmt.Fprintf(flags.Output(), "unknown format: %s\n", *output_format)
+			return EXIT_CODE_USAGE()
+		}
+
+		database_installer, database_installer_errors := newDatabaseInstaller(installer_flags)
+		if database_installer_errors != nil {
+			return printErrors(database_installer_errors)
+		}
+
+		install_status, install_status_errors := database_installer.Status()
+		if install_status_errors != nil {
+			return printErrors(install_status_errors)
+		}
+
+		if *output_format == "json" {
+			var json_payload strings.Builder
+			json_errors := install_status.ToJSONString(&json_payload)
+			if json_errors != nil {
+				return printErrors(json_errors)
+			}
+			fmt.Println(json_payload.String())
+		} else {
+			fmt.Print(install_status.ToString())
+		}
+		return EXIT_CODE_SUCCESS()
+	}))
+
+	commands = append(commands, newCommand("verify", "compare the server and credential files with the config, exits with 0 in sync, 3 on drift and 1 on errors", func(flags *flag.FlagSet, arguments []string) int {
+		installer_flags := addInstallerFlags(flags)
+		output_format := flags.String("format", "text", "output format: text or json")
+		if ok, exit_code := parseFlags(flags, arguments); !ok {
+			return exit_code
+		}
+
+		if *output_format != "text" && *output_format != "json" {
+			fmt.Fprintf(flags.Output(), "unknown format: %s\n", *output_format)
+			return EXIT_CODE_USAGE()
+		}
+
+		database_installer, database_installer_errors := newDatabaseInstaller(installer_flags)
+		if database_installer_errors != nil {
+			return printErrors(database_installer_errors)
+		}
+
+		install_status, install_status_errors := database_installer.Status()
+		if install_status_errors != nil {
+			return printErrors(install_status_errors)
+		}
+
+		if *output_format == "json" {
+			var json_payload strings.Builder
+			json_errors := install_status.ToJSONString(&json_payload)
+			if json_errors != nil {
+				return printErrors(json_errors)
+			}
+			fmt.Println(json_payload.String())
+		} else {
+			fmt.Print(install_status.ToString())
+		}
+
+		if !install_status.IsInstalled() {
+			return EXIT_CODE_DRIFT()
+		}
+		return EXIT_CODE_SUCCESS()
+	}))
+
+	commands = append(commands, newCommand("reconcile", "revoke privileges the mysql roles and managed users hold beyond their roles and grant the missing ones", func(flags *flag.FlagSet, arguments []string) int {
+		installer_flags := addInstallerFlags(flags)
+		report_only := flags.Bool("report-only", false, "only report drift without changing any grants, exits with 3 when drift is found")
+		output_format := flags.String("format", "text", "output format: text or json")
+		if ok, exit_code := parseFlags(flags, arguments); !ok {
+			return exit_code
+		}
+
+		if *output_format != "text" && *output_format != "json" {
+			fmt.Fprintf(flags.Output(), "unknown format: %s\n", *output_format)
+			return EXIT_CODE_USAGE()
+		}
+
+		database_installer, database_installer_errors := newDatabaseInstaller(installer_flags)
+		if database_installer_errors != nil {
+			return printErrors(database_installer_errors)
+		}
+
+		drift_report, reconcile_errors := database_installer.Reconcile(*report_only)
+		if drift_report != nil {
+			if *output_format == "json" {
+				var json_payload strings.Builder
+				json_errors := drift_report.ToJSONString(&json_payload)
+				if json_errors != nil {
+					return printErrors(json_errors)
+				}
+				fmt.Println(json_payload.String())
+			} else {
+				fmt.Print(drift_report.ToString())
+			}
+		}
+
+		if reconcile_errors != nil {
+			return printErrors(reconcile_errors)
+		}
+
+		if *report_only && drift_report.HasDrift() {
+			return EXIT_CODE_DRIFT()
+		}
+		return EXIT_CODE_SUCCESS()
+	}))
+
+	commands = append(commands, newCommand("rotate", "rotate the passwords of one or more roles and rewrite only their credential files", func(flags *flag.FlagSet, arguments []string) int {
+		installer_flags := addInstallerFlags(flags)
+		raw_roles := flags.String("roles", "", "comma separated roles to rotate: migration, write, read, a configured role or a pool member such as write:3")
+		retain_current_password := flags.Bool("retain-current-password", false, "keep the current password valid next to the new one (MySQL 8 dual passwords) until discard-old-passwords")
+		if ok, exit_code := parseFlags(flags, arguments); !ok {
+			return exit_code
+		}
+
+		if *raw_roles == "" {
+			fmt.Fprintln(flags.Output(), "rotate requires --roles")
+			return EXIT_CODE_USAGE()
+		}
+
+		database_installer, database_installer_errors := newDatabaseInstaller(installer_flags)
+		if database_installer_errors != nil {
+			return printErrors(database_installer_errors)
+		}
+
+		var rotate_errors []error
+		if *retain_current_password {
+			rotate_errors = database_installer.RotateCredentialsWithRetainedPassword(strings.Split(*raw_roles, ",")...)
+		} else {
+			rotate_errors = database_installer.RotateCredentials(strings.Split(*raw_roles, ",")...)
+		}
+
+		if rotate_errors != nil {
+			return printErrors(rotate_errors)
+		}
+		return EXIT_CODE_SUCCESS()
+	}))
+
+	commands = append(commands, newCommand("discard-old-passwords", "discard the passwords retained by rotate --retain-current-password", func(flags *flag.FlagSet, arguments []string) int {
+		installer_flags := addInstallerFlags(flags)
+		raw_roles := flags.String("roles", "", "comma separated roles: migration, write, read, a configured role or a pool member such as write:3")
+		if ok, exit_code := parseFlags(flags, arguments); !ok {
+			return exit_code
+		}
+
+		if *raw_roles == "" {
+			fmt.Fprintln(flags.Output(), "discard-old-passwords requires --roles")
+			return EXIT_CODE_USAGE()
+		}
+
+		database_installer, database_installer_errors := newDatabaseInstaller(installer_flags)
+		if database_installer_errors != nil {
+			return printErrors(database_installer_errors)
+		}
+
+		discard_errors := database_installer.DiscardOldPasswords(strings.Split(*raw_roles, ",")...)
+		if discard_errors != nil {
+			return printErrors(discard_errors)
+		}
+		return EXIT_CODE_SUCCESS()
+	}))
+
+	commands = append(commands, newCommand("uninstall", "drop the managed users, remove their credential files and optionally drop the database", func(flags *flag.FlagSet, arguments []string) int {
+		installer_flags := addInstallerFlags(flags)
+		confirm_database_name := flags.String("confirm", "", "name of the database being uninstalled, required to confirm the uninstall")
+		keep_data := flags.Bool("keep-data", false, "keep the database and the DatabaseMigration table, only remove users and credential files")
+		if ok, exit_code := parseFlags(flags, arguments); !ok {
+			return exit_code
+		}
+
+		if *confirm_database_name == "" {
+			fmt.Fprintln(flags.Output(), "uninstall requires --confirm=<database name>")
+			return EXIT_CODE_USAGE()
+		}
+
+		database_installer, database_installer_errors := newDatabaseInstaller(installer_flags)
+		if database_installer_errors != nil {
+			return printErrors(database_installer_errors)
+		}
+
+		uninstall_errors := database_installer.Uninstall(*confirm_database_name, *keep_data)
+		if uninstall_errors != nil {
+			return printErrors(uninstall_errors)
+		}
+		return EXIT_CODE_SUCCESS()
+	}))
+
+	commands = append(commands, newCommand("write-credentials", "write the root credential files for every host user, and the role credential files of an applied export", func(flags *flag.FlagSet, arguments []string) int {
+		installer_flags := addInstallerFlags(flags)
+		secrets_file := flags.String("secrets-file", "", "secrets file of an export, its script must have been applied, also writes the credential files of the role users with its passwords")
+		if ok, exit_code := parseFlags(flags, arguments); !ok {
+			return exit_code
+		}
+
+		database_installer, database_installer_errors := newDatabaseInstaller(installer_flags)
+		if database_installer_errors != nil {
+			return printErrors(database_installer_errors)
+		}
+
+		write_credentials_errors := database_installer.WriteCredentials()
+		if write_credentials_errors != nil {
+			return printErrors(write_credentials_errors)
+		}
+
+		if *secrets_file != "" {
+			write_exported_credentials_errors := database_installer.WriteExportedCredentials(*secrets_file)
+			if write_exported_credentials_errors != nil {
+				return printErrors(write_exported_credentials_errors)
+			}
+		}
+		return EXIT_CODE_SUCCESS()
+	}))
+
+	return commands
+}
+
+func printUsage(commands []Command) {
+	fmt.Fprintln(os.Stderr, "usage: holistic_db_init <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, command := range commands {
+		fmt.Fprintf(os.Stderr, "  %-18s %s\n", command.GetName(), command.GetDescription())
+	}
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "flags override the HOLISTIC_DATABASE_* environment variables.")
+	fmt.Fprintln(os.Stderr, "run holistic_db_init <command> -h for the flags of a command.")
+}
+
+func main() {
+	commands := getCommands()
+
+	// without a command the installer keeps its original behaviour of a full install driven by environment variables
+	command_name := "install"
+	var arguments []string
+	if len(os.Args) > 1 {
+		command_name = os.Args[1]
+		arguments = os.Args[2:]
+	}
+
+	if command_name == "help" || command_name == "-h" || command_name == "--help" {
+		printUsage(commands)
+		os.Exit(EXIT_CODE_SUCCESS())
+	}
+
+	for _, command := range commands {
+		if command.GetName() == command_name {
+			os.Exit(command.Run(arguments))
+		}
 	}
 
-	os.Exit(0)
-}
\ No newline at end of file
+	fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", command_name)
+	printUsage(commands)
+	os.Exit(EXIT_CODE_USAGE())
+}